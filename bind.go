@@ -0,0 +1,128 @@
+package cli
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/mailund/cli/internal/flags"
+	"github.com/mailund/cli/internal/params"
+	"github.com/mailund/cli/internal/vals"
+)
+
+// recentRun remembers the most recent instance of a command, so Source and
+// IsSet can report on it. It is shared between a command and its instances.
+type recentRun struct {
+	mu  sync.Mutex
+	cmd *Command
+}
+
+// newArgv returns a new argv from the spec's Init function, or nil if the
+// spec doesn't have one.
+func (cmd *Command) newArgv() interface{} {
+	if cmd.Init == nil {
+		return nil
+	}
+
+	return cmd.Init()
+}
+
+// bind creates new flags and parameters for the command and connects them
+// to argv, which should be a value returned from the spec's Init function.
+func (cmd *Command) bind(argv interface{}) error {
+	cmd.flags, cmd.params, cmd.argv = flags.NewFlagSet(), params.NewParamSet(), argv
+	cmd.command, cmd.cmdArgs = "", nil
+
+	// There is always a help command when we parse, but the usage won't
+	// show it unless we make it explicit
+	hf := vals.FuncNoValue(showHelp(cmd.Usage))
+	_ = cmd.flags.Var(hf, "help", "h", fmt.Sprintf("show help for %s", cmd.Name)) // cannot fail
+
+	// Tools can get a description of the command with --help-json, but users don't need to see it
+	jf := vals.FuncNoValue(func() error {
+		if err := cmd.writeDescription(); err != nil {
+			return err
+		}
+
+		return ErrHelp
+	})
+	_ = cmd.flags.Var(jf, "help-json", "", "print a description of the command as JSON") // cannot fail
+	cmd.flags.Lookup("help-json").Hidden = true
+
+	if cmd.VersionFlag {
+		vf := vals.FuncNoValue(func() error {
+			if err := cmd.writeVersion(false); err != nil {
+				return err
+			}
+
+			return ErrHelp
+		})
+		_ = cmd.flags.Var(vf, "version", "", "show the version") // cannot fail
+	}
+
+	if argv != nil {
+		if err := connectSpecsFlagsAndParams(cmd, argv); err != nil {
+			return err
+		}
+	}
+
+	if len(cmd.subcommands) > 0 {
+		cmd.params.Var((*vals.StringValue)(&cmd.command), "cmd", "sub-command to call")
+		cmd.params.VariadicVar((*vals.VariadicStringValue)(&cmd.cmdArgs), "...", "argument for sub-commands", 0)
+	}
+
+	return nil
+}
+
+// quietBuiltins replaces the callbacks of the built-in flags, which print help,
+// descriptions, or versions, with callbacks that only stop parsing, as the
+// flags do.
+func (cmd *Command) quietBuiltins() {
+	builtins := []string{"help", "help-json"}
+	if cmd.VersionFlag {
+		builtins = append(builtins, "version")
+	}
+
+	for _, name := range builtins {
+		cmd.flags.Lookup(name).Value = vals.FuncNoValue(func() error { return ErrHelp })
+	}
+}
+
+// instance returns a copy of the command with its own argv, flags, and
+// parameters, so each run of a command starts from a fresh state and
+// concurrent runs do not share any. The command itself keeps the flags
+// and parameters it got when it was created, for usage and documentation.
+func (cmd *Command) instance() (*Command, error) {
+	return cmd.instanceFor(cmd.newArgv())
+}
+
+// instanceFor returns a copy of the command with flags and parameters bound
+// to argv.
+func (cmd *Command) instanceFor(argv interface{}) (*Command, error) {
+	inst := *cmd
+	if err := inst.bind(argv); err != nil {
+		return nil, err
+	}
+
+	return &inst, nil
+}
+
+// setRecent records inst as the most recent instance of the command.
+func (cmd *Command) setRecent(inst *Command) {
+	cmd.recent.mu.Lock()
+	defer cmd.recent.mu.Unlock()
+
+	cmd.recent.cmd = inst
+}
+
+// mostRecent returns the most recent instance of the command, or the
+// command itself if it hasn't been run yet.
+func (cmd *Command) mostRecent() *Command {
+	cmd.recent.mu.Lock()
+	defer cmd.recent.mu.Unlock()
+
+	if cmd.recent.cmd != nil {
+		return cmd.recent.cmd
+	}
+
+	return cmd
+}
@@ -0,0 +1,154 @@
+package cli_test
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/mailund/cli"
+	"github.com/mailund/cli/interfaces"
+)
+
+func TestFreshArgvPerRun(t *testing.T) {
+	type Args struct {
+		Verbose bool     `flag:"verbose"`
+		Xs      []string `pos:"xs"`
+	}
+
+	var seen []Args
+
+	cmd := cli.NewCommand(cli.CommandSpec{
+		Name:   "cmd",
+		Init:   func() interface{} { return new(Args) },
+		Action: func(i interface{}) { seen = append(seen, *i.(*Args)) },
+	})
+
+	for _, args := range [][]string{{"--verbose", "a", "b"}, {"c"}} {
+		if err := cmd.RunError(args); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+	}
+
+	expected := []Args{{Verbose: true, Xs: []string{"a", "b"}}, {Xs: []string{"c"}}}
+	if !reflect.DeepEqual(seen, expected) {
+		t.Errorf("state leaked between runs: %v", seen)
+	}
+}
+
+func TestFreshSubcommandPerRun(t *testing.T) {
+	type Args struct {
+		N int `flag:"n"`
+	}
+
+	var ns []int
+
+	sub := cli.NewCommand(cli.CommandSpec{
+		Name:   "sub",
+		Init:   func() interface{} { return new(Args) },
+		Action: func(i interface{}) { ns = append(ns, i.(*Args).N) },
+	})
+	cmd := cli.NewMenu("cmd", "", "", sub)
+
+	for _, args := range [][]string{{"sub", "-n", "42"}, {"sub"}} {
+		if err := cmd.RunError(args); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+	}
+
+	if !reflect.DeepEqual(ns, []int{42, 0}) {
+		t.Errorf("state leaked between runs: %v", ns)
+	}
+}
+
+func TestConcurrentRuns(t *testing.T) {
+	type Args struct {
+		N int `flag:"n"`
+	}
+
+	const runs = 20
+
+	var (
+		mu      sync.Mutex
+		results = map[int]bool{}
+	)
+
+	cmd := cli.NewCommand(cli.CommandSpec{
+		Name: "cmd",
+		Init: func() interface{} { return new(Args) },
+		ActionContext: func(ctx context.Context, i interface{}) error {
+			n := i.(*Args).N
+
+			mu.Lock()
+			defer mu.Unlock()
+
+			results[n] = cli.IsSet(ctx, "n") == (n != 0)
+
+			return nil
+		},
+	})
+
+	var wg sync.WaitGroup
+
+	for i := 0; i < runs; i++ {
+		wg.Add(1)
+
+		go func(n int) {
+			defer wg.Done()
+
+			args := []string{}
+			if n != 0 {
+				args = []string{"-n", fmt.Sprint(n)}
+			}
+
+			if err := cmd.RunError(args); err != nil {
+				t.Errorf("unexpected error: %s", err)
+			}
+		}(i)
+	}
+
+	wg.Wait()
+
+	for i := 0; i < runs; i++ {
+		if ok, seen := results[i]; !seen || !ok {
+			t.Errorf("run with n=%d did not see its own arguments", i)
+		}
+	}
+}
+
+func TestSourceContext(t *testing.T) {
+	type Args struct {
+		N int `flag:"n"`
+	}
+
+	var (
+		src   interfaces.Provenance
+		found bool
+	)
+
+	cmd := cli.NewCommand(cli.CommandSpec{
+		Name: "cmd",
+		Init: func() interface{} { return new(Args) },
+		ActionContext: func(ctx context.Context, _ interface{}) error {
+			src, found = cli.Source(ctx, "n")
+			return nil
+		},
+	})
+
+	if err := cmd.RunError([]string{"-n", "42"}); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if !found || src.Source != interfaces.SourceCommandLine || src.Raw != "42" {
+		t.Errorf("unexpected source: %v (found %t)", src, found)
+	}
+
+	if _, ok := cli.Source(context.Background(), "n"); ok {
+		t.Error("a context not from a command should not have sources")
+	}
+
+	if cli.IsSet(context.Background(), "n") {
+		t.Error("a context not from a command should not have set arguments")
+	}
+}
@@ -30,6 +30,11 @@ func (c *Choice) String() string {
 	return c.Choice
 }
 
+// Complete implements the Completer protocol
+func (c *Choice) Complete(prefix string) []string {
+	return withPrefix(c.Options, prefix)
+}
+
 // ArgumentDescription implements the ArgumentDescription protocol
 func (c *Choice) ArgumentDescription(flag bool, descr string) string {
 	if flag {
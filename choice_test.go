@@ -6,7 +6,6 @@ import (
 	"testing"
 
 	"github.com/mailund/cli"
-	"github.com/mailund/cli/internal/failure"
 )
 
 type Args struct {
@@ -29,7 +28,7 @@ var cmd = cli.NewCommand(
 	})
 
 func TestUsage(t *testing.T) {
-	failure.Failure = func() {}
+	cmd.SetExitFunc(func(int) {})
 
 	builder := new(strings.Builder)
 	cmd.SetOutput(builder)
@@ -84,7 +83,7 @@ func TestSet(t *testing.T) {
 	}
 
 	failed := false
-	failure.Failure = func() { failed = true }
+	cmd.SetExitFunc(func(int) { failed = true })
 	builder := new(strings.Builder)
 
 	cmd.SetOutput(builder)
@@ -94,14 +93,14 @@ func TestSet(t *testing.T) {
 		t.Error("we expected to fail")
 	}
 
-	expected := "Error: parsing flag -a: X is not a valid choice, must be in {A,B,C}.\n"
+	expected := "Error: parsing flag -a: X is not a valid choice, must be in {A,B,C}.\n\nUsage: choices"
 	msg := builder.String()
 
 	space := regexp.MustCompile(`\s+`)
 	msg = space.ReplaceAllString(msg, " ")
 	expected = space.ReplaceAllString(expected, " ")
 
-	if msg != expected {
+	if !strings.HasPrefix(msg, expected) {
 		t.Errorf("unexpected: %s", msg)
 		t.Errorf("unexpected: %s", expected)
 	}
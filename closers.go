@@ -0,0 +1,67 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/mailund/cli/interfaces"
+)
+
+// closeValue closes val if it implements io.Closer and it was opened through
+// Set or PrepareValue. If the value implements interfaces.Opener, it tells us
+// whether it opened what it holds. Otherwise, we assume that it did if it was
+// set, and not if it holds its default, since that belongs to whoever gave it.
+func closeValue(what string, val interface{}, src interfaces.Source) error {
+	closer, ok := val.(io.Closer)
+	if !ok {
+		return nil
+	}
+
+	opened := src != interfaces.SourceDefault
+	if o, ok := val.(interfaces.Opener); ok {
+		opened = o.Opened()
+	}
+
+	if !opened {
+		return nil // we didn't open it, so it isn't ours to close
+	}
+
+	// Actions that close their files themselves are fine, so a file that is
+	// already closed is not an error.
+	if err := closer.Close(); err != nil && !errors.Is(err, os.ErrClosed) {
+		return fmt.Errorf("error closing %s: %w", what, err)
+	}
+
+	return nil
+}
+
+// closeValues closes the command's flag and parameter values that the command
+// might have opened. It closes all of them, even if some fail, and returns the
+// first error.
+func (cmd *Command) closeValues() error {
+	var errs []error
+
+	for i := 0; i < cmd.flags.NFlags(); i++ {
+		f := cmd.flags.Flag(i)
+		errs = append(errs, closeValue("flag "+f.Names(), f.Value, f.Provenance.Source))
+	}
+
+	for i := 0; i < cmd.params.NParams(); i++ {
+		p := cmd.params.Param(i)
+		errs = append(errs, closeValue("argument "+p.Name, p.Value, p.Provenance.Source))
+	}
+
+	if vv := cmd.params.Variadic(); vv != nil {
+		errs = append(errs, closeValue("argument "+vv.Name, vv.Value, vv.Provenance.Source))
+	}
+
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,225 @@
+package cli_test
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/mailund/cli"
+)
+
+// closeValue is a value that records whether it was closed.
+type closeValue struct {
+	val    string
+	closed bool
+	err    error
+}
+
+func (c *closeValue) Set(x string) error {
+	c.val = x
+	return nil
+}
+func (c *closeValue) String() string { return c.val }
+func (c *closeValue) Close() error {
+	c.closed = true
+	return c.err
+}
+
+// prepareCloseValue is a closeValue that PrepareValue opens if open is set.
+type prepareCloseValue struct {
+	closeValue
+	open, opened bool
+}
+
+func (p *prepareCloseValue) PrepareValue() error {
+	p.opened = p.open
+	return nil
+}
+func (p *prepareCloseValue) Opened() bool { return p.opened }
+
+type closeArgs struct {
+	Set       closeValue        `flag:"set"`
+	Unset     closeValue        `flag:"unset"`
+	Prepare   prepareCloseValue `flag:"prepare"`
+	NoPrepare prepareCloseValue `flag:"no-prepare"`
+	Pos       closeValue        `pos:"pos"`
+}
+
+func TestCloseAfterAction(t *testing.T) {
+	var (
+		argv         = &closeArgs{Prepare: prepareCloseValue{open: true}}
+		openInAction bool
+	)
+
+	cmd := cli.NewCommand(cli.CommandSpec{
+		Name: "cmd",
+		Init: func() interface{} { return argv },
+		Action: func(interface{}) {
+			openInAction = !argv.Set.closed && !argv.Pos.closed && !argv.Prepare.closed
+		},
+	})
+
+	if err := cmd.RunError([]string{"--set", "x", "y"}); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if !openInAction {
+		t.Error("values should not be closed before the action runs")
+	}
+
+	if !argv.Set.closed || !argv.Pos.closed || !argv.Prepare.closed {
+		t.Errorf("set and prepared values should be closed: %+v", argv)
+	}
+
+	if argv.Unset.closed || argv.NoPrepare.closed {
+		t.Error("values we didn't open should not be closed")
+	}
+}
+
+func TestNoCloseCallerFile(t *testing.T) {
+	type args struct {
+		Log cli.OutFile `flag:"log"`
+	}
+
+	logFile, err := ioutil.TempFile(t.TempDir(), "log")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer logFile.Close()
+
+	cmd := cli.NewCommand(cli.CommandSpec{
+		Name: "cmd",
+		Init: func() interface{} { return &args{Log: cli.OutFile{Writer: logFile}} },
+	})
+
+	for i := 0; i < 2; i++ {
+		if err := cmd.RunError([]string{}); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+	}
+
+	if _, err := logFile.WriteString("still open"); err != nil {
+		t.Errorf("the caller's file should still be open: %s", err)
+	}
+}
+
+func TestCloseOnParseError(t *testing.T) {
+	argv := new(closeArgs)
+	cmd := cli.NewCommand(cli.CommandSpec{
+		Name:   "cmd",
+		Init:   func() interface{} { return argv },
+		Action: func(interface{}) { t.Error("the action should not run") },
+	})
+
+	if err := cmd.RunError([]string{"--set", "x"}); err == nil {
+		t.Fatal("expected a missing argument error")
+	}
+
+	if !argv.Set.closed {
+		t.Error("values opened before the parse error should be closed")
+	}
+
+	if argv.Unset.closed || argv.Pos.closed {
+		t.Error("values we didn't open should not be closed")
+	}
+}
+
+func TestCloseNesting(t *testing.T) {
+	type args struct {
+		Val closeValue `flag:"val"`
+	}
+
+	var (
+		parent, child  = new(args), new(args)
+		parentOpen     bool
+		childClosedPre bool
+	)
+
+	sub := cli.NewCommand(cli.CommandSpec{
+		Name: "sub",
+		Init: func() interface{} { return child },
+		Action: func(interface{}) {
+			parentOpen = !parent.Val.closed
+		},
+	})
+	cmd := cli.NewCommand(cli.CommandSpec{
+		Name:        "cmd",
+		Init:        func() interface{} { return parent },
+		Subcommands: []*cli.Command{sub},
+		PostRun: func(_ context.Context, _ interface{}) error {
+			childClosedPre = child.Val.closed
+			return nil
+		},
+	})
+
+	if err := cmd.RunError([]string{"--val", "x", "sub", "--val", "y"}); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if !parentOpen {
+		t.Error("the parent's values should be open while the subcommand runs")
+	}
+
+	if !childClosedPre {
+		t.Error("the subcommand's values should be closed before the parent's PostRun")
+	}
+
+	if !parent.Val.closed || !child.Val.closed {
+		t.Error("all values should be closed at the end")
+	}
+}
+
+func TestCloseError(t *testing.T) {
+	closeErr := errors.New("close failed")
+	argv := &closeArgs{Set: closeValue{err: closeErr}}
+	cmd := cli.NewCommand(cli.CommandSpec{
+		Name: "cmd",
+		Init: func() interface{} { return argv },
+	})
+
+	err := cmd.RunError([]string{"--set", "x", "y"})
+	if !errors.Is(err, closeErr) {
+		t.Fatalf("expected the close error, got %v", err)
+	}
+
+	if !strings.Contains(err.Error(), "flag --set") {
+		t.Errorf("the error should name the flag: %s", err)
+	}
+
+	if !argv.Pos.closed {
+		t.Error("the other values should be closed even if one fails")
+	}
+}
+
+func TestNoCloseStdStreams(t *testing.T) {
+	type args struct {
+		In  cli.InFile  `flag:"in"`
+		Out cli.OutFile `flag:"out"`
+		Err cli.OutFile `flag:"err"`
+	}
+
+	argv := &args{
+		In:  cli.InFile{Reader: os.Stdin},
+		Out: cli.OutFile{Writer: os.Stdout},
+		Err: cli.OutFile{Writer: os.Stderr},
+	}
+	cmd := cli.NewCommand(cli.CommandSpec{
+		Name: "cmd",
+		Init: func() interface{} { return argv },
+	})
+
+	if err := cmd.RunError([]string{}); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if argv.In.Reader != os.Stdin || argv.Out.Writer != os.Stdout || argv.Err.Writer != os.Stderr {
+		t.Error("the standard streams should be left alone")
+	}
+
+	if _, err := os.Stdout.Stat(); err != nil {
+		t.Errorf("stdout should still be open: %s", err)
+	}
+}
@@ -1,16 +1,15 @@
 package cli
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"os"
-	"sort"
+	"text/template"
 
 	"github.com/mailund/cli/interfaces"
-	"github.com/mailund/cli/internal/failure"
 	"github.com/mailund/cli/internal/flags"
 	"github.com/mailund/cli/internal/params"
-	"github.com/mailund/cli/internal/vals"
 )
 
 // CommandSpec defines a commandline command or subcommand
@@ -21,6 +20,9 @@ type CommandSpec struct {
 	Short string
 	// Long is used for displaying documentation for a command.
 	Long string
+	// Examples show how to use the command, in its usage and in generated documentation.
+	// Use VerifyExamples in tests to check that they are still valid.
+	Examples []Example
 	// Init is a callback that should create a structure that specifies flags and positional
 	// parameters (via reflection) plus default values and any other data the command needs.
 	Init func() interface{}
@@ -30,11 +32,60 @@ type CommandSpec struct {
 	// there are any. The argument to Action is the structure returned from Init(), after flags and positional
 	// arguments are parsed.
 	Action func(interface{})
+	// ActionError is an alternative to Action for actions that can fail. If it returns an
+	// error, RunError returns it, Run prints it and handles it according to the command's
+	// error handling policy, and the command's subcommands are not invoked. A spec can
+	// have either an Action or an ActionError, but not both.
+	ActionError func(interface{}) error
+	// ActionContext is an alternative to Action for actions that can fail and that need a
+	// context. The context is cancelled if the program receives SIGINT or SIGTERM, see
+	// RunErrorContext, and it holds the command path and the parsed arguments of parent
+	// commands, see CommandPath and ParentArgv. Errors are handled as for ActionError. A
+	// spec can only have one of Action, ActionError, and ActionContext.
+	ActionContext func(context.Context, interface{}) error
+	// PreRun is called after the command line is parsed and before the command's action.
+	// If it returns an error, the error is handled as an error from the action.
+	PreRun Hook
+	// PostRun is called after the command's action, and after any subcommands have
+	// finished, if they all succeeded.
+	PostRun Hook
+	// Finally is called after the command and any subcommands have finished, even if
+	// an action or a hook failed or panicked, as long as the command line could be
+	// parsed.
+	Finally Hook
+	// PersistentPreRun, PersistentPostRun, and PersistentFinally work as PreRun,
+	// PostRun, and Finally, but apply to the command and all its descendants. They run
+	// once per run, around the command that handles the command line: the persistent
+	// pre-run hooks of the commands on its path run before its PreRun, outermost first,
+	// and the persistent post-run and finally hooks run after its PostRun and Finally,
+	// innermost first. Each hook gets the argv of the command it belongs to.
+	PersistentPreRun  Hook
+	PersistentPostRun Hook
+	PersistentFinally Hook
 	// Usage is a callback to print usage information about a command. In most cases, you should leave
 	// it undefined and rely on the default usage.
 	Usage func()
+	// UsageTemplate is a text/template for the default usage, executed with a *UsageData.
+	// If it is empty, the command uses DefaultUsageTemplate. To set the template for a
+	// whole command tree, use SetUsageTemplate on its root.
+	UsageTemplate string
 	// Subcommands holds a list of subcommands.
 	Subcommands []*Command
+	// Hidden commands can be run as normal, but are not listed in their parent's
+	// usage or in generated completions.
+	Hidden bool
+	// Category is the heading the command is listed under in its parent's usage. Commands
+	// without a category are listed first, under "Commands".
+	Category string
+	// Version is the program's version, shown by the --version flag and the command from
+	// VersionCommand. If it is empty, the version comes from the module's build information.
+	// Subcommands use the version of the nearest command above them that has one.
+	Version string
+	// VersionFlag gives the command a --version flag that prints the program's version.
+	VersionFlag bool
+	// DeclarationOrder lists the command's subcommands in the order they are declared,
+	// in Subcommands and then by AddSubcommands, rather than sorted by name.
+	DeclarationOrder bool
 }
 
 // Command wraps a command line (sub)command. It is created from a CommandSpec and is the functional
@@ -47,10 +98,19 @@ type Command struct {
 	argv   interface{}
 	out    io.Writer
 
+	usageTemplate *template.Template // the template for the default usage
+
 	// for subcommands
 	subcommands map[string]*Command
 	command     string
 	cmdArgs     []string
+
+	parent        *Command   // the command this is a subcommand of, if any
+	recent        *recentRun // the most recent run of the command
+	config        ConfigLookup
+	collectErrors bool
+	errorHandling ErrorHandling
+	exit          func(int)
 }
 
 // Output returns the writer the command will write usage information to.
@@ -66,6 +126,16 @@ func (cmd *Command) SetOutput(out io.Writer) {
 	cmd.out = out
 }
 
+// Path returns the names of the commands leading to cmd, starting with the
+// top-level command and ending with cmd's own name.
+func (cmd *Command) Path() []string {
+	if cmd.parent == nil {
+		return []string{cmd.Name}
+	}
+
+	return subPath(cmd.parent.Path(), cmd.Name)
+}
+
 // Usage prints usage information about a command.
 func (cmd *Command) Usage() { cmd.CommandSpec.Usage() }
 
@@ -77,63 +147,261 @@ func (cmd *Command) SetUsage(usage func()) {
 	cmd.CommandSpec.Usage = usage
 }
 
-// RunError parses options and arguments from args and then executes the
-// command. This function returns an error if there are errors parsing or preparing
-// the command line arguments, and the command's error handling flag is
-// failure.ContinueOnError. You most likely want to use the Run() method
-// instead, unless you have good reasons to capture errors rather than
-// terminate your program on parsing errors.
-func (cmd *Command) RunError(args []string) error {
-	if err := cmd.flags.Parse(args); err != nil {
-		return err
+// Source reports where the flag or positional argument with the given name got
+// its value from in the most recent run of the command. Flags can be looked up
+// by either their long or short name. The second return value is false if the
+// command doesn't have an argument with that name. If the command runs in more
+// than one goroutine at a time, the most recent run is not well defined, and
+// you should use the Source function with the context the command runs in
+// instead.
+func (cmd *Command) Source(name string) (interfaces.Provenance, bool) {
+	return cmd.mostRecent().source(name)
+}
+
+// source reports where the argument with the given name got its value from
+// in this instance of the command.
+func (cmd *Command) source(name string) (interfaces.Provenance, bool) {
+	if f := cmd.flags.Lookup(name); f != nil {
+		return f.Provenance, true
+	}
+
+	for i := 0; i < cmd.params.NParams(); i++ {
+		if p := cmd.params.Param(i); p.Name == name {
+			return p.Provenance, true
+		}
+	}
+
+	if vv := cmd.params.Variadic(); vv != nil && vv.Name == name {
+		return vv.Provenance, true
+	}
+
+	return interfaces.Provenance{}, false
+}
+
+// IsSet reports whether the flag or positional argument with the given name
+// was explicitly given a value, rather than holding its default, in the most
+// recent run of the command.
+func (cmd *Command) IsSet(name string) bool {
+	prov, ok := cmd.Source(name)
+	return ok && prov.Source != interfaces.SourceDefault
+}
+
+// SetCollectErrors sets whether the command, and all its subcommands, should
+// stop at the first error when parsing a command line, or collect all the
+// errors and report them together. With collect set, RunError will return
+// parsing errors as interfaces.ParseErrors.
+func (cmd *Command) SetCollectErrors(collect bool) {
+	for _, sub := range cmd.Subcommands {
+		sub.SetCollectErrors(collect)
+	}
+
+	cmd.collectErrors = collect
+}
+
+// AddSubcommands adds subcommands to a command after it is created, for example
+// commands that need the command tree they are part of, such as the command from
+// CompletionCommand. The subcommands get the command's output and error handling.
+// It is an error to add subcommands to a command with a variadic parameter.
+func (cmd *Command) AddSubcommands(subcmds ...*Command) error {
+	if len(cmd.subcommands) == 0 && cmd.params.Variadic() != nil {
+		return interfaces.SpecErrorf("a command with subcommands cannot have variadic parameters")
 	}
 
-	if err := cmd.params.Parse(cmd.flags.Args()); err != nil {
-		return err
+	if cmd.subcommands == nil {
+		cmd.subcommands = map[string]*Command{}
 	}
 
-	if err := prepareFlagsAndParams(cmd); err != nil {
-		return err
+	cmd.Subcommands = append(append([]*Command{}, cmd.Subcommands...), subcmds...)
+
+	for _, sub := range subcmds {
+		cmd.subcommands[sub.Name] = sub
+		sub.parent = cmd
+
+		sub.SetOutput(cmd.out)
+		sub.SetErrorHandling(cmd.errorHandling)
+		sub.SetExitFunc(cmd.exit)
+		sub.SetCollectErrors(cmd.collectErrors)
+	}
+
+	// Rebind to get the parameters for the subcommand name and its arguments.
+	// This cannot fail, since it worked before and we checked for variadics.
+	return cmd.bind(cmd.newArgv())
+}
+
+// annotate adds the command path to parse errors, and shifts the index of
+// the offending token by offset.
+func annotate(err error, path []string, offset int) error {
+	if errs, ok := err.(interfaces.ParseErrors); ok {
+		for _, e := range errs {
+			annotate(e, path, offset)
+		}
 	}
 
-	// Invoke the action for this (sub)command
-	if cmd.Action != nil {
-		cmd.Action(cmd.argv)
+	if perr, ok := err.(*interfaces.ParseError); ok {
+		if perr.Path == nil {
+			perr.Path = path
+		}
+
+		if perr.Index >= 0 {
+			perr.Index += offset
+		}
 	}
 
-	// then, if there are sub-commands, dispatch
-	if len(cmd.subcommands) > 0 {
-		subcmd, ok := cmd.subcommands[cmd.command]
-		if !ok {
-			return interfaces.ParseErrorf("'%s' is not a valid command for %s.\n\n", cmd.command, cmd.Name)
+	return err
+}
+
+// parse parses and prepares the flags and positional arguments for the
+// command.
+func (cmd *Command) parse(path, args []string) error {
+	if !cmd.collectErrors {
+		if err := cmd.flags.Parse(args); err != nil {
+			return annotate(err, path, 0)
 		}
 
-		return subcmd.RunError(cmd.cmdArgs)
+		if err := cmd.setFromSources(path, false); err != nil {
+			return annotate(err, path, 0)
+		}
+
+		if err := cmd.params.Parse(cmd.flags.Args()); err != nil {
+			return annotate(err, path, len(args)-len(cmd.flags.Args()))
+		}
+
+		if err := prepareFlagsAndParams(cmd, false); err != nil {
+			return annotate(err, path, 0)
+		}
+
+		return annotate(validateArgs(cmd.argv), path, 0)
+	}
+
+	var errs interfaces.ParseErrors
+
+	for _, err := range []error{
+		annotate(cmd.flags.ParseAll(args), path, 0),
+		annotate(cmd.setFromSources(path, true), path, 0),
+		annotate(cmd.params.ParseAll(cmd.flags.Args()), path, len(args)-len(cmd.flags.Args())),
+		annotate(prepareFlagsAndParams(cmd, true), path, 0),
+	} {
+		if pe, ok := err.(interfaces.ParseErrors); ok {
+			errs = append(errs, pe...)
+		} else if err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+
+	if err := validateArgs(cmd.argv); err != nil {
+		return interfaces.ParseErrors{annotate(err, path, 0)}
 	}
 
 	return nil
 }
 
+// action invokes the command's action, if it has one.
+func (cmd *Command) action(ctx context.Context, argv interface{}) error {
+	switch {
+	case cmd.Action != nil:
+		cmd.Action(argv)
+	case cmd.ActionError != nil:
+		return cmd.ActionError(argv)
+	case cmd.ActionContext != nil:
+		return cmd.ActionContext(ctx, argv)
+	}
+
+	return nil
+}
+
+// run parses the command line and then runs the commands it selects, and returns
+// the command where an error occurred together with the error. If the command line
+// asks for completion candidates, it prints those instead.
+func (cmd *Command) run(ctx context.Context, args []string) (*Command, error) {
+	if len(args) > 0 && args[0] == completeCommand {
+		return cmd, cmd.writeCompletions(args[1:])
+	}
+
+	inv, failed, err := cmd.parseInvocation(args, (*Command).instance)
+	if err != nil {
+		return failed, err
+	}
+
+	// Only catch signals if something can see that the context is cancelled,
+	// otherwise the program should terminate on the first signal as usual.
+	if inv.usesContext() {
+		var stop func()
+
+		ctx, stop = withSignals(ctx, cmd.exit)
+		defer stop()
+	}
+
+	return inv.run(ctx, 0)
+}
+
+// RunError parses options and arguments from args and then executes the
+// command. This function returns an error if there are errors parsing or preparing
+// the command line arguments, regardless of the command's error handling policy.
+// If the command line asks for help, the usage is printed and RunError returns
+// ErrHelp. You most likely want to use the Run() method instead, unless you have
+// good reasons to capture errors rather than terminate your program on parsing
+// errors.
+func (cmd *Command) RunError(args []string) error {
+	return cmd.RunErrorContext(context.Background(), args)
+}
+
+// RunErrorContext works as RunError, but runs the command with a context derived
+// from ctx. If a command on the path has an ActionContext or a hook, the context
+// is cancelled if the program receives SIGINT or SIGTERM, and a second signal
+// terminates the program through the command's exit function. Otherwise, signals
+// are left alone.
+func (cmd *Command) RunErrorContext(ctx context.Context, args []string) error {
+	_, err := cmd.run(ctx, args)
+	if isHelp(err) {
+		return ErrHelp
+	}
+
+	return err
+}
+
 // Run parses options and arguments from args and then executes the
 // command.
 //
-// Parsing errors for either flags or parameters will terminate the program
-// with os.Exit(0) for -help options and os.Exit(2) otherwise. If the parsing
-// is succesfull, the underlying run callback is executed.
+// Errors are printed to the command's output and then handled according to
+// the command's error handling policy, see SetErrorHandling. With the default,
+// ExitOnError, parsing errors for either flags or parameters will terminate the
+// program with exit status 0 for -help options and 2 otherwise. If the parsing
+// is succesfull, the underlying run callback is executed. Parsing errors are
+// followed by the usage of the (sub)command where they occurred, and if the
+// command collects errors, all of them are printed before it.
 func (cmd *Command) Run(args []string) {
-	if err := cmd.RunError(args); err != nil {
-		fmt.Fprintf(cmd.out, "Error: %s.\n", err)
-		failure.Failure()
+	cmd.RunContext(context.Background(), args)
+}
+
+// RunContext works as Run, but runs the command with a context derived from ctx,
+// which is cancelled on signals as described for RunErrorContext.
+func (cmd *Command) RunContext(ctx context.Context, args []string) {
+	failed, err := cmd.run(ctx, args)
+	if err == nil {
+		return
 	}
+
+	if isHelp(err) {
+		err = ErrHelp
+	}
+
+	cmd.handleError(failed, err)
 }
 
-func showHelp(usage func()) func() error {
-	return func() error {
-		usage()
-		failure.Success()
+func countActions(spec *CommandSpec) int {
+	n := 0
 
-		return nil
+	for _, isSet := range []bool{spec.Action != nil, spec.ActionError != nil, spec.ActionContext != nil} {
+		if isSet {
+			n++
+		}
 	}
+
+	return n
 }
 
 // NewCommandError Create a new command. The function returns a new command object or an error.
@@ -145,44 +413,47 @@ func showHelp(usage func()) func() error {
 // annotated so parsed arguments are automatically configured. Then the Action parameter
 // will be invoked when the commandline gets to the command.
 func NewCommandError(spec CommandSpec) (*Command, error) { //nolint:gocritic // specs are large but only copied when we create a command
-	cmd := &Command{
-		CommandSpec: spec,
-		flags:       flags.NewFlagSet(),
-		params:      params.NewParamSet()}
+	if countActions(&spec) > 1 {
+		return nil, interfaces.SpecErrorf("a command spec can only have one of Action, ActionError, and ActionContext")
+	}
 
-	const linewidth = 70
-	cmd.Long = wordWrap(cmd.Long, linewidth)
+	cmd := &Command{CommandSpec: spec, recent: &recentRun{}}
 
 	if spec.Usage == nil {
 		cmd.SetUsage(DefaultUsage(cmd))
 	}
 
-	// There is always a help command when we parse, but the usage won't
-	// show it unless we make it explicit
-	hf := vals.FuncNoValue(showHelp(cmd.Usage))
-	_ = cmd.flags.Var(hf, "help", "h", fmt.Sprintf("show help for %s", cmd.Name)) // cannot fail
-
-	if spec.Init != nil {
-		cmd.argv = spec.Init()
+	usageTemplate := spec.UsageTemplate
+	if usageTemplate == "" {
+		usageTemplate = DefaultUsageTemplate
+	}
 
-		if err := connectSpecsFlagsAndParams(cmd, cmd.argv); err != nil {
-			return nil, err
-		}
+	tmpl, err := parseUsageTemplate(usageTemplate)
+	if err != nil {
+		return nil, err
 	}
 
-	cmd.SetOutput(os.Stdout)
+	cmd.usageTemplate = tmpl
 
 	if len(cmd.Subcommands) > 0 {
 		cmd.subcommands = map[string]*Command{}
 
 		for _, sub := range cmd.Subcommands {
 			cmd.subcommands[sub.Name] = sub
+			sub.parent = cmd
 		}
+	}
 
-		cmd.params.Var((*vals.StringValue)(&cmd.command), "cmd", "sub-command to call")
-		cmd.params.VariadicVar((*vals.VariadicStringValue)(&cmd.cmdArgs), "...", "argument for sub-commands", 0)
+	// The command gets its own flags and parameters for usage and
+	// documentation. Each run creates new ones.
+	if err := cmd.bind(cmd.newArgv()); err != nil {
+		return nil, err
 	}
 
+	cmd.SetOutput(os.Stdout)
+	cmd.SetErrorHandling(ExitOnError)
+	cmd.SetExitFunc(os.Exit)
+
 	return cmd, nil
 }
 
@@ -202,45 +473,6 @@ func NewCommand(spec CommandSpec) *Command { //nolint:gocritic // specs are larg
 	return cmd
 }
 
-// DefaultUsage creates a default function for printing usage information,
-// getting the information to print from the cmd object.
-func DefaultUsage(cmd *Command) func() {
-	return func() {
-		fmt.Fprintf(cmd.Output(),
-			"Usage: %s [flags] %s\n\n",
-			cmd.Name, cmd.params.ShortUsage())
-
-		if cmd.Long != "" {
-			fmt.Fprintf(cmd.Output(), "%s\n\n", cmd.Long)
-		} else {
-			fmt.Fprintf(cmd.Output(), "%s\n\n", cmd.Short)
-		}
-
-		// Print options and arguments at the bottom.
-		fmt.Fprintf(cmd.out, "\n")
-		cmd.flags.PrintDefaults(cmd.out)
-		fmt.Fprintf(cmd.out, "\n")
-		cmd.params.PrintDefaults(cmd.out)
-
-		if len(cmd.subcommands) > 0 {
-			fmt.Fprintf(cmd.Output(), "\nCommands:\n")
-
-			subcmdNames := []string{}
-			for name := range cmd.subcommands {
-				subcmdNames = append(subcmdNames, name)
-			}
-
-			sort.Strings(subcmdNames)
-
-			for _, name := range subcmdNames {
-				fmt.Fprintf(cmd.Output(), "  %s\n\t%s\n", name, cmd.subcommands[name].Short)
-			}
-
-			fmt.Fprintf(cmd.Output(), "\n")
-		}
-	}
-}
-
 // NewMenu creates a menu command and panics if there are errors.
 // This is a convinience wrapper around a NewCommand with a spec
 // that has subcommands. It returns a new command or an error. It can only error
@@ -263,5 +495,9 @@ func NewMenu(name, short, long string, subcmds ...*Command) *Command {
 		Subcommands: subcmds,
 	})
 
+	if _, ok := cmd.subcommands[helpCommand]; !ok {
+		_ = cmd.AddSubcommands(HelpCommand(cmd)) // cannot fail for a menu
+	}
+
 	return cmd
 }
@@ -1,13 +1,16 @@
 package cli_test
 
 import (
+	"errors"
+	"fmt"
+	"os"
 	"reflect"
 	"regexp"
 	"strings"
 	"testing"
 
 	"github.com/mailund/cli"
-	"github.com/mailund/cli/internal/failure"
+	"github.com/mailund/cli/interfaces"
 )
 
 func TestNewCommand(t *testing.T) {
@@ -106,7 +109,7 @@ Arguments:
 		t.Errorf("Expected usage message %s but got %s\n", expected, msg)
 	}
 
-	failure.Success = func() {}
+	cmd.SetExitFunc(func(int) {})
 
 	builder = new(strings.Builder)
 	cmd.SetOutput(builder)
@@ -266,8 +269,6 @@ func TestParam(t *testing.T) {
 		argX = a.(*Testargs).X
 	}
 
-	failure.Failure = func() { failed = true }
-
 	cmd := cli.NewCommand(cli.CommandSpec{
 		Name:   "foo",
 		Short:  "does foo",
@@ -275,6 +276,7 @@ func TestParam(t *testing.T) {
 		Init:   init,
 		Action: action,
 	})
+	cmd.SetExitFunc(func(int) { failed = true })
 
 	builder := new(strings.Builder)
 	cmd.SetOutput(builder)
@@ -326,6 +328,56 @@ func makeMenu() (xCalled, yCalled *bool, cmd *cli.Command) {
 	return xCalled, yCalled, cmd
 }
 
+// withOutput sends cmd's output to a new builder and returns both.
+func withOutput(cmd *cli.Command) (*cli.Command, *strings.Builder) {
+	out := new(strings.Builder)
+	cmd.SetOutput(out)
+
+	return cmd, out
+}
+
+// toolTree returns the command tree most tests use: a tool with flags, a calc
+// menu with a visible and a hidden subcommand, and completion and help commands.
+// Its output goes to the returned builder.
+func toolTree(t *testing.T) (*cli.Command, *strings.Builder) {
+	t.Helper()
+
+	type (
+		RootArgs struct {
+			Mode    cli.Choice `flag:"mode" short:"m" descr:"the mode"`
+			In      cli.InFile `flag:"in" short:"i" descr:"input file"`
+			Verbose bool       `flag:"verbose" short:"v"`
+		}
+		AddArgs struct {
+			File cli.InFile `pos:"file"`
+		}
+	)
+
+	add := cli.NewCommand(cli.CommandSpec{
+		Name:  "add",
+		Short: "add things",
+		Init:  func() interface{} { return new(AddArgs) },
+	})
+	secret := cli.NewCommand(cli.CommandSpec{Name: "secret", Hidden: true})
+	calc := cli.NewMenu("calc", "a calculator", "", add, secret)
+	root := cli.NewCommand(cli.CommandSpec{
+		Name: "tool",
+		Init: func() interface{} {
+			return &RootArgs{
+				Mode: cli.Choice{Choice: "fast", Options: []string{"fast", "slow"}},
+				In:   cli.InFile{Reader: os.Stdin},
+			}
+		},
+		Subcommands: []*cli.Command{calc},
+	})
+
+	if err := root.AddSubcommands(cli.CompletionCommand(root), cli.HelpCommand(root)); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	return withOutput(root)
+}
+
 func TestMenu(t *testing.T) {
 	xCalled, yCalled, menu := makeMenu()
 
@@ -373,8 +425,8 @@ func TestMenuUsage(t *testing.T) {
 
 func TestMenuFailure(t *testing.T) {
 	failed := false
-	failure.Failure = func() { failed = true }
 	_, _, menu := makeMenu()
+	menu.SetExitFunc(func(int) { failed = true })
 
 	builder := new(strings.Builder)
 	menu.SetOutput(builder)
@@ -392,7 +444,8 @@ func TestMenuFailure(t *testing.T) {
 	menu.SetOutput(builder)
 	menu.Run([]string{"x", "--foo"})
 
-	if errmsg := builder.String(); !strings.HasSuffix(errmsg, "flag provided but not defined: --foo.\n") {
+	expected := "Error: flag provided but not defined: --foo.\n\nUsage: menu x"
+	if errmsg := builder.String(); !strings.HasPrefix(errmsg, expected) {
 		t.Errorf("(2) Expected different error message than %s\n", errmsg)
 	}
 }
@@ -523,3 +576,283 @@ func TestValueInterface(t *testing.T) {
 		t.Error("Variadic argument wasn't set correctly")
 	}
 }
+
+func TestIsSet(t *testing.T) {
+	type Args struct {
+		Retries int      `flag:"retries" short:"r"`
+		Name    string   `flag:"name"`
+		X       int      `pos:"x"`
+		Files   []string `pos:"files"`
+	}
+
+	var (
+		retries, name, x, files, unknown bool
+		retriesSrc                       interfaces.Provenance
+	)
+
+	cmd := cli.NewCommand(cli.CommandSpec{
+		Init: func() interface{} { return &Args{Retries: 3} },
+	})
+	cmd.Action = func(interface{}) {
+		retries, name, x, files = cmd.IsSet("retries"), cmd.IsSet("name"), cmd.IsSet("x"), cmd.IsSet("files")
+		_, found := cmd.Source("unknown")
+		unknown = !found
+		retriesSrc, _ = cmd.Source("r")
+	}
+
+	if err := cmd.RunError([]string{"42"}); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if retries || name || !x || files || !unknown {
+		t.Errorf("unexpected IsSet: retries=%t name=%t x=%t files=%t", retries, name, x, files)
+	}
+
+	if retriesSrc.Source != interfaces.SourceDefault || retriesSrc.Raw != "3" {
+		t.Errorf("unexpected source for retries: %v", retriesSrc)
+	}
+
+	if err := cmd.RunError([]string{"-r", "5", "42", "a.txt"}); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if !retries || name || !x || !files {
+		t.Errorf("unexpected IsSet: retries=%t name=%t x=%t files=%t", retries, name, x, files)
+	}
+
+	if retriesSrc.Source != interfaces.SourceCommandLine || retriesSrc.Raw != "5" {
+		t.Errorf("unexpected source for retries: %v", retriesSrc)
+	}
+
+	if retriesSrc.Source.String() != "command line" {
+		t.Errorf("unexpected source string: %s", retriesSrc.Source)
+	}
+}
+
+func TestSources(t *testing.T) {
+	type Args struct {
+		Retries int    `flag:"retries" env:"CLI_TEST_RETRIES"`
+		Name    string `flag:"name" env:"CLI_TEST_NAME"`
+		Mode    string `flag:"mode"`
+	}
+
+	var retries, name, mode interfaces.Provenance
+
+	cmd := cli.NewCommand(cli.CommandSpec{
+		Name: "cmd",
+		Init: func() interface{} { return new(Args) },
+	})
+	cmd.Action = func(interface{}) {
+		retries, _ = cmd.Source("retries")
+		name, _ = cmd.Source("name")
+		mode, _ = cmd.Source("mode")
+	}
+	cmd.SetConfig(func(path []string, name string) (string, bool) {
+		if strings.Join(path, " ") == "cmd" && (name == "mode" || name == "name") {
+			return "from-config", true
+		}
+
+		return "", false
+	})
+
+	if err := os.Setenv("CLI_TEST_RETRIES", "7"); err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = os.Unsetenv("CLI_TEST_RETRIES") }()
+
+	if err := cmd.RunError([]string{"--name=foo"}); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if retries.Source != interfaces.SourceEnvironment || retries.Raw != "7" {
+		t.Errorf("unexpected source for retries: %v", retries)
+	}
+
+	if name.Source != interfaces.SourceCommandLine || name.Raw != "foo" {
+		t.Errorf("unexpected source for name: %v", name)
+	}
+
+	if mode.Source != interfaces.SourceConfig || mode.Raw != "from-config" {
+		t.Errorf("unexpected source for mode: %v", mode)
+	}
+
+	if err := cmd.RunError([]string{"--retries=2"}); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if retries.Source != interfaces.SourceCommandLine || name.Source != interfaces.SourceConfig {
+		t.Errorf("unexpected sources: retries=%v name=%v", retries, name)
+	}
+
+	if err := os.Setenv("CLI_TEST_RETRIES", "foo"); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `parsing flag --retries from environment variable CLI_TEST_RETRIES: argument "foo" cannot be parsed as int`
+	if err := cmd.RunError([]string{}); err == nil || err.Error() != expected {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestSourcesSkipCallbacks(t *testing.T) {
+	type Args struct {
+		Verbose bool               `flag:"verbose"`
+		Log     func(string) error `flag:"log"`
+	}
+
+	called, logged := false, false
+	cmd, out := withOutput(cli.NewCommand(cli.CommandSpec{
+		Name:        "cmd",
+		VersionFlag: true,
+		Init: func() interface{} {
+			return &Args{Log: func(string) error { logged = true; return nil }}
+		},
+		Action: func(interface{}) { called = true },
+	}))
+	cmd.SetConfig(func(path []string, name string) (string, bool) { return "false", true })
+
+	if err := cmd.RunError([]string{}); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if !called || logged || out.String() != "" {
+		t.Errorf("only the verbose flag should come from the configuration: called=%t logged=%t output=%q",
+			called, logged, out.String())
+	}
+
+	if src, _ := cmd.Source("verbose"); src.Source != interfaces.SourceConfig {
+		t.Errorf("unexpected source for verbose: %v", src)
+	}
+}
+
+func TestCollectErrors(t *testing.T) {
+	type Args struct {
+		N int     `flag:"n" descr:"an integer"`
+		X float64 `pos:"x" descr:"a float"`
+		Y int     `pos:"y" descr:"an integer"`
+	}
+
+	failed, called := false, false
+
+	sub := cli.NewCommand(cli.CommandSpec{
+		Name:   "sub",
+		Short:  "a subcommand",
+		Init:   func() interface{} { return new(Args) },
+		Action: func(interface{}) { called = true },
+	})
+	menu := cli.NewMenu("menu", "", "", sub)
+	menu.SetCollectErrors(true)
+	menu.SetExitFunc(func(int) { failed = true })
+
+	err := menu.RunError([]string{"sub", "-n", "foo", "--bar", "x"})
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+
+	errs, ok := err.(interfaces.ParseErrors)
+	if !ok {
+		t.Fatalf("expected ParseErrors but got %T", err)
+	}
+
+	if len(errs) != 4 {
+		t.Errorf("expected four errors but got: %s", err)
+	}
+
+	builder := new(strings.Builder)
+	menu.SetOutput(builder)
+	menu.Run([]string{"sub", "-n", "foo", "--bar", "x"})
+
+	if !failed || called {
+		t.Errorf("expected the command to fail without running the action")
+	}
+
+	expected := `Error: parsing flag -n: argument "foo" cannot be parsed as int.
+Error: flag provided but not defined: --bar.
+Error: error parsing parameter x='x'.
+Error: missing argument y.
+
+Usage: menu sub [flags] x y
+`
+	if msg := builder.String(); !strings.HasPrefix(msg, expected) {
+		t.Errorf("unexpected output:\n%s", msg)
+	}
+
+	builder = new(strings.Builder)
+	menu.SetOutput(builder)
+	menu.Run([]string{"baz"})
+
+	if msg := builder.String(); !strings.HasPrefix(msg, "Error: 'baz' is not a valid command for menu.") ||
+		!strings.Contains(msg, "Usage: menu [flags] cmd ...") {
+		t.Errorf("unexpected output:\n%s", msg)
+	}
+}
+
+var errAction = errors.New("action failed")
+
+type exitCodeError struct{}
+
+func (exitCodeError) Error() string { return "exit code error" }
+func (exitCodeError) ExitCode() int { return 42 }
+
+func TestActionError(t *testing.T) {
+	subCalled := false
+	sub := cli.NewCommand(cli.CommandSpec{
+		Name:   "sub",
+		Action: func(interface{}) { subCalled = true },
+	})
+	cmd := cli.NewCommand(cli.CommandSpec{
+		Name:        "cmd",
+		ActionError: func(interface{}) error { return errAction },
+		Subcommands: []*cli.Command{sub},
+	})
+
+	if err := cmd.RunError([]string{"sub"}); !errors.Is(err, errAction) {
+		t.Errorf("expected the action's error but got %v", err)
+	}
+
+	if subCalled {
+		t.Error("the subcommand should not be called when the parent fails")
+	}
+
+	status := 0
+	builder := new(strings.Builder)
+
+	cmd.SetOutput(builder)
+	cmd.SetExitFunc(func(code int) { status = code })
+	cmd.Run([]string{"sub"})
+
+	if status != 1 {
+		t.Errorf("expected exit status 1 but got %d", status)
+	}
+
+	if msg := builder.String(); msg != "Error: action failed.\n" {
+		t.Errorf("unexpected output: %s", msg)
+	}
+}
+
+func TestExitCoder(t *testing.T) {
+	cmd := cli.NewCommand(cli.CommandSpec{
+		ActionError: func(interface{}) error { return fmt.Errorf("wrapped: %w", exitCodeError{}) },
+	})
+
+	status := 0
+
+	cmd.SetOutput(new(strings.Builder))
+	cmd.SetExitFunc(func(code int) { status = code })
+	cmd.Run([]string{})
+
+	if status != 42 { //nolint:gomnd // the exit code from exitCodeError
+		t.Errorf("expected exit status 42 but got %d", status)
+	}
+}
+
+func TestActionAndActionError(t *testing.T) {
+	_, err := cli.NewCommandError(cli.CommandSpec{
+		Action:      func(interface{}) {},
+		ActionError: func(interface{}) error { return nil },
+	})
+
+	if err == nil || err.Error() != "a command spec can only have one of Action, ActionError, and ActionContext" {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
@@ -0,0 +1,209 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/mailund/cli/interfaces"
+	"github.com/mailund/cli/internal/flags"
+	"github.com/mailund/cli/internal/vals"
+)
+
+// completeCommand is the hidden command-line entry point for dynamic completion.
+// If the first argument to a top-level command is completeCommand, the command
+// prints the completion candidates for the last of the remaining arguments.
+const completeCommand = "__complete"
+
+// completeWith calls complete, if it isn't nil, with prefix.
+func completeWith(complete func(string) []string, prefix string) []string {
+	if complete == nil {
+		return nil
+	}
+
+	return complete(prefix)
+}
+
+// withPrefix returns the strings in xs that start with prefix.
+func withPrefix(xs []string, prefix string) []string {
+	res := []string{}
+
+	for _, x := range xs {
+		if strings.HasPrefix(x, prefix) {
+			res = append(res, x)
+		}
+	}
+
+	return res
+}
+
+// valueFlag returns the flag that takes its value from the argument after word,
+// or nil if word isn't such a flag.
+func (cmd *Command) valueFlag(word string) *flags.Flag {
+	var f *flags.Flag
+
+	switch {
+	case strings.HasPrefix(word, "--") && !strings.Contains(word, "="):
+		f = cmd.flags.Lookup(word[2:])
+	case len(word) > 1 && word[0] == '-' && word[1] != '-':
+		f = cmd.flags.Lookup(word[len(word)-1:]) // only the last short flag can take a value
+	}
+
+	if f != nil && f.TakesValue() {
+		return f
+	}
+
+	return nil
+}
+
+// flagNames returns the names of the command's flags, as they are written
+// on the command line.
+func (cmd *Command) flagNames() []string {
+	names := []string{}
+
+	for _, f := range cmd.visibleFlags() {
+		names = append(names, f.Spellings()...)
+	}
+
+	return names
+}
+
+// noCallback replaces a callback value with one that does nothing, and leaves
+// other values as they are.
+func noCallback(val interface{}) interface{} {
+	switch val.(type) {
+	case vals.FuncNoValue:
+		return vals.FuncNoValue(func() error { return nil })
+	case vals.FuncValue:
+		return vals.FuncValue(func(string) error { return nil })
+	case vals.VariadicFuncValue:
+		return vals.VariadicFuncValue(func([]string) error { return nil })
+	default:
+		return val
+	}
+}
+
+// completionInstance returns an instance of the command for completion. Like
+// the instance we verify examples with, it doesn't print usage or open files,
+// and in addition, callbacks do nothing, so completing a command line doesn't
+// have any side effects.
+func (cmd *Command) completionInstance() (*Command, error) {
+	inst, err := cmd.dryInstance()
+	if err != nil {
+		return nil, err
+	}
+
+	for i := 0; i < inst.flags.NFlags(); i++ {
+		f := inst.flags.Flag(i)
+		f.Value, _ = noCallback(f.Value).(interfaces.FlagValue)
+	}
+
+	for i := 0; i < inst.params.NParams(); i++ {
+		p := inst.params.Param(i)
+		p.Value, _ = noCallback(p.Value).(interfaces.PosValue)
+	}
+
+	if vv := inst.params.Variadic(); vv != nil {
+		vv.Value, _ = noCallback(vv.Value).(interfaces.VariadicValue)
+	}
+
+	return inst, nil
+}
+
+// completeArg returns the completion candidates for the word cur, for the
+// positional arguments in rest that come before it.
+func (cmd *Command) completeArg(rest []string, cur string) []string {
+	if len(cmd.subcommands) > 0 && len(rest) == cmd.params.NParams()-1 {
+		names := []string{}
+
+		for _, sub := range cmd.visibleSubcommands() {
+			names = append(names, sub.Name)
+		}
+
+		return withPrefix(names, cur)
+	}
+
+	if len(rest) < cmd.params.NParams() {
+		return completeWith(cmd.params.Param(len(rest)).Complete, cur)
+	}
+
+	if vv := cmd.params.Variadic(); vv != nil {
+		return completeWith(vv.Complete, cur)
+	}
+
+	return nil
+}
+
+// complete returns the completion candidates for the last word in args. The
+// words before it are parsed, with the command's flags and parameters, to find
+// the (sub)command and argument the last word belongs to. Values are set as when
+// parsing normally, so completers can depend on the values of earlier arguments,
+// except that files are not opened and callbacks are not called, and no actions
+// or hooks run.
+func (cmd *Command) complete(args []string) []string {
+	if len(args) == 0 {
+		args = []string{""}
+	}
+
+	words, cur := args[:len(args)-1], args[len(args)-1]
+
+	inst, err := cmd.completionInstance()
+	if err != nil {
+		return nil
+	}
+
+	defer func() { _ = inst.closeValues() }() // there is no one to report errors to
+
+	_ = inst.flags.ParseAll(words) // we complete as well as we can, even with errors
+	rest := inst.flags.Args()
+
+	if len(rest) == 0 && len(words) > 0 {
+		if f := inst.valueFlag(words[len(words)-1]); f != nil {
+			return completeWith(f.Complete, cur)
+		}
+	}
+
+	// the subcommand name comes after the command's other positional arguments
+	if lead := inst.params.NParams() - 1; len(inst.subcommands) > 0 && len(rest) > lead {
+		sub, ok := inst.subcommands[rest[lead]]
+		if !ok {
+			return nil
+		}
+
+		return sub.complete(append(append([]string{}, rest[lead+1:]...), cur))
+	}
+
+	_ = inst.params.ParseAll(rest) // so completers can see earlier positional arguments
+
+	switch {
+	case strings.HasPrefix(cur, "--") && strings.Contains(cur, "="):
+		eq := strings.Index(cur, "=")
+		if f := inst.flags.Lookup(cur[2:eq]); f != nil {
+			candidates := completeWith(f.Complete, cur[eq+1:])
+			for i := range candidates {
+				candidates[i] = cur[:eq+1] + candidates[i]
+			}
+
+			return candidates
+		}
+
+		return nil
+
+	case strings.HasPrefix(cur, "-") && len(rest) == 0:
+		return withPrefix(inst.flagNames(), cur)
+
+	default:
+		return inst.completeArg(rest, cur)
+	}
+}
+
+// writeCompletions prints the completion candidates for the last word in args,
+// one per line.
+func (cmd *Command) writeCompletions(args []string) error {
+	for _, candidate := range cmd.complete(args) {
+		if _, err := fmt.Fprintln(cmd.Output(), candidate); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
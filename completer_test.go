@@ -0,0 +1,226 @@
+package cli_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/mailund/cli"
+)
+
+type branchArgs struct {
+	Branch  string     `flag:"branch" short:"b" complete:"Branches"`
+	Verbose bool       `flag:"verbose" short:"v"`
+	Mode    cli.Choice `flag:"mode"`
+	Files   []string   `pos:"files" complete:"CompleteFiles"`
+}
+
+func (a *branchArgs) Branches(prefix string) []string {
+	branches := []string{}
+
+	for _, b := range []string{"main", "master", "dev"} {
+		if strings.HasPrefix(b, prefix) {
+			branches = append(branches, b)
+		}
+	}
+
+	return branches
+}
+
+func (a *branchArgs) CompleteFiles(prefix string) []string {
+	return []string{prefix + "-on-" + a.Branch}
+}
+
+func TestDynamicCompletion(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		expected []string
+	}{
+		{"no words", []string{}, []string{"checkout"}},
+		{"subcommands", []string{"ch"}, []string{"checkout"}},
+		{"unknown subcommand", []string{"foo", ""}, []string{}},
+		{"flags", []string{"checkout", "--"}, []string{"--help", "--branch", "--verbose", "--mode"}},
+		{"short flags", []string{"checkout", "-v", "-"}, []string{"--help", "-h", "--branch", "-b", "--verbose", "-v", "--mode"}},
+		{"flag value", []string{"checkout", "--branch", "ma"}, []string{"main", "master"}},
+		{"short flag value", []string{"checkout", "-vb", ""}, []string{"main", "master", "dev"}},
+		{"flag with equals", []string{"checkout", "--branch=d"}, []string{"--branch=dev"}},
+		{"choice", []string{"checkout", "--mode", "s"}, []string{"safe"}},
+		{"positional sees flags", []string{"checkout", "-b", "dev", "x"}, []string{"x-on-dev"}},
+		{"variadic", []string{"checkout", "a", "b", "c"}, []string{"c-on-"}},
+		{"no completer", []string{"checkout", "--mode=fast", "-v", "--verbose", ""}, []string{"-on-"}},
+		{"help does not print", []string{"checkout", "-h", "--br"}, []string{"--branch"}},
+	}
+
+	checkout := cli.NewCommand(cli.CommandSpec{
+		Name: "checkout",
+		Init: func() interface{} {
+			return &branchArgs{Mode: cli.Choice{Choice: "fast", Options: []string{"fast", "safe"}}}
+		},
+		Action: func(interface{}) { t.Error("completion should not run actions") },
+	})
+	hidden := cli.NewCommand(cli.CommandSpec{Name: "hidden", Hidden: true})
+	cmd, out := withOutput(cli.NewMenu("git", "", "", checkout, hidden))
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			out.Reset()
+			if err := cmd.RunError(append([]string{"__complete"}, tt.args...)); err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			candidates := strings.Fields(out.String())
+			if len(candidates) == 0 {
+				candidates = []string{}
+			}
+
+			if !reflect.DeepEqual(candidates, tt.expected) {
+				t.Errorf("got %q, expected %q", candidates, tt.expected)
+			}
+		})
+	}
+}
+
+type deployArgs struct {
+	Env string `pos:"env" complete:"Envs"`
+}
+
+func (a *deployArgs) Envs(prefix string) []string {
+	return []string{prefix + "-env"}
+}
+
+func TestCompletionBeforeSubcommand(t *testing.T) {
+	deploy := cli.NewCommand(cli.CommandSpec{Name: "deploy", Action: func(interface{}) {}})
+	cmd, out := withOutput(cli.NewCommand(cli.CommandSpec{
+		Name:        "tool",
+		Init:        func() interface{} { return new(deployArgs) },
+		Subcommands: []*cli.Command{deploy},
+	}))
+
+	tests := []struct {
+		args     []string
+		expected []string
+	}{
+		{[]string{"prod"}, []string{"prod-env"}},
+		{[]string{"prod", "de"}, []string{"deploy"}},
+		{[]string{"prod", "deploy", "--"}, []string{"--help"}},
+		{[]string{"prod", "nope", ""}, []string{}},
+	}
+
+	for _, tt := range tests {
+		out.Reset()
+
+		if err := cmd.RunError(append([]string{"__complete"}, tt.args...)); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+
+		candidates := strings.Fields(out.String())
+		if len(candidates) == 0 {
+			candidates = []string{}
+		}
+
+		if !reflect.DeepEqual(candidates, tt.expected) {
+			t.Errorf("%v: got %q, expected %q", tt.args, candidates, tt.expected)
+		}
+	}
+}
+
+func TestCompletionHasNoSideEffects(t *testing.T) {
+	type args struct {
+		Out      cli.OutFile        `flag:"out"`
+		In       cli.InFile         `pos:"in"`
+		Callback func(string) error `flag:"callback"`
+	}
+
+	fname := filepath.Join(t.TempDir(), "existing.txt")
+	if err := ioutil.WriteFile(fname, []byte("important data"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	called := false
+	cmd, out := withOutput(cli.NewCommand(cli.CommandSpec{
+		Name: "tool",
+		Init: func() interface{} {
+			return &args{Out: cli.OutFile{Writer: os.Stdout}, Callback: func(string) error { called = true; return nil }}
+		},
+		Action: func(interface{}) { t.Error("completion should not run actions") },
+	}))
+
+	for _, args := range [][]string{
+		{"--out", fname, ""},
+		{"--callback", "x", ""},
+		{"--help-json", "--"},
+		{"no-such-file", ""},
+	} {
+		out.Reset()
+
+		if err := cmd.RunError(append([]string{"__complete"}, args...)); err != nil {
+			t.Fatalf("unexpected error for %v: %s", args, err)
+		}
+
+		if strings.Contains(out.String(), "{") {
+			t.Errorf("completion should only print candidates for %v, got %q", args, out.String())
+		}
+	}
+
+	if data, err := ioutil.ReadFile(fname); err != nil || string(data) != "important data" {
+		t.Errorf("completion should not touch files, got %q (%v)", data, err)
+	}
+
+	if called {
+		t.Error("completion should not call callbacks")
+	}
+}
+
+func TestChoiceCompleter(t *testing.T) {
+	c := cli.Choice{Options: []string{"foo", "bar", "baz"}}
+	if candidates := c.Complete("ba"); !reflect.DeepEqual(candidates, []string{"bar", "baz"}) {
+		t.Errorf("unexpected candidates: %v", candidates)
+	}
+}
+
+func TestCompleterSpecErrors(t *testing.T) {
+	type noMethod struct {
+		X string `flag:"x" complete:"Missing"`
+	}
+
+	if _, err := cli.NewCommandError(cli.CommandSpec{Init: func() interface{} { return new(noMethod) }}); err == nil {
+		t.Error("expected an error for a missing completion method")
+	}
+
+	if _, err := cli.NewCommandError(cli.CommandSpec{Init: func() interface{} { return new(badSigArgs) }}); err == nil {
+		t.Error("expected an error for a completion method with the wrong signature")
+	}
+}
+
+type badSigArgs struct {
+	X string `pos:"x" complete:"Bad"`
+}
+
+func (a *badSigArgs) Bad(int) []string { return nil }
+
+func TestDynamicCompletionScripts(t *testing.T) {
+	checkout := cli.NewCommand(cli.CommandSpec{Name: "checkout", Init: func() interface{} { return new(branchArgs) }})
+	cmd := cli.NewMenu("git", "", "", checkout)
+	out := new(strings.Builder)
+
+	if err := cmd.WriteCompletion(out, "bash"); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	checkContains(t, out.String(),
+		`'git checkout --branch'|'git checkout -b') COMPREPLY=($("${COMP_WORDS[0]}" __complete`,
+		`COMPREPLY+=($("${COMP_WORDS[0]}" __complete "${COMP_WORDS[@]:1:COMP_CWORD}" 2>/dev/null))`)
+
+	out.Reset()
+
+	if err := cmd.WriteCompletion(out, "fish"); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	checkContains(t, out.String(), `-l branch -s b -x -a '((commandline -opc)[1] __complete`)
+}
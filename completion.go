@@ -0,0 +1,342 @@
+package cli
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/mailund/cli/interfaces"
+	"github.com/mailund/cli/internal/flags"
+)
+
+// Shells we can generate completion scripts for
+var completionShells = []string{"bash", "fish", "zsh"}
+
+// complFlag is what we need to know about a flag to complete it
+type complFlag struct {
+	long, short string
+	names       []string // the names as they are written on the command line
+	descr       string
+	noValue     bool     // the flag doesn't take a separate value
+	files       bool     // the value is a file name
+	values      []string // the possible values, if there is a fixed set
+	dynamic     bool     // the program completes the value itself
+}
+
+// complCmd is what we need to know about a command to complete it
+type complCmd struct {
+	path    string // names of the commands leading to this one, separated by spaces
+	subs    []*Command
+	flags   []complFlag
+	files   bool // the positional arguments are file names
+	dynamic bool // the program completes the positional arguments itself
+}
+
+func isFileValue(val interface{}) bool {
+	switch val.(type) {
+	case *InFile, *OutFile:
+		return true
+	default:
+		return false
+	}
+}
+
+func newComplFlag(f *flags.Flag) complFlag {
+	// values for flags with defaults can only be given as --flag=value
+	cf := complFlag{long: f.Long, short: f.Short, names: f.Spellings(), descr: f.Desc, noValue: !f.TakesValue()}
+
+	switch v := f.Value.(type) {
+	case *Choice:
+		cf.values = v.Options
+	default:
+		cf.files = isFileValue(v)
+		cf.dynamic = f.Complete != nil
+	}
+
+	return cf
+}
+
+// visibleSubcommands returns the subcommands that are not hidden, sorted by name
+// or in declaration order if the command has DeclarationOrder set.
+func (cmd *Command) visibleSubcommands() []*Command {
+	subs := []*Command{}
+
+	for _, sub := range cmd.Subcommands {
+		if !sub.Hidden && cmd.subcommands[sub.Name] == sub {
+			subs = append(subs, sub)
+		}
+	}
+
+	if !cmd.DeclarationOrder {
+		sort.SliceStable(subs, func(i, j int) bool { return subs[i].Name < subs[j].Name })
+	}
+
+	return subs
+}
+
+// completionTree collects the completion information for cmd and its
+// (visible) subcommands, in a depth-first order.
+func (cmd *Command) completionTree(path string) []*complCmd {
+	cc := &complCmd{path: path, subs: cmd.visibleSubcommands()}
+
+	for _, f := range cmd.visibleFlags() {
+		cc.flags = append(cc.flags, newComplFlag(f))
+	}
+
+	for i := 0; i < cmd.params.NParams(); i++ {
+		p := cmd.params.Param(i)
+		cc.files = cc.files || isFileValue(p.Value)
+		cc.dynamic = cc.dynamic || p.Complete != nil
+	}
+
+	if vv := cmd.params.Variadic(); vv != nil {
+		cc.dynamic = cc.dynamic || vv.Complete != nil
+	}
+
+	tree := []*complCmd{cc}
+	for _, sub := range cc.subs {
+		tree = append(tree, sub.completionTree(path+" "+sub.Name)...)
+	}
+
+	return tree
+}
+
+func (cc *complCmd) subNames() []string {
+	names := make([]string, len(cc.subs))
+	for i, sub := range cc.subs {
+		names[i] = sub.Name
+	}
+
+	return names
+}
+
+var nonIdentifier = regexp.MustCompile(`[^A-Za-z0-9_]`)
+
+// completionFunc is the name of the shell function that completes the command.
+func (cmd *Command) completionFunc() string {
+	return "__" + nonIdentifier.ReplaceAllString(cmd.Name, "_") + "_complete"
+}
+
+// shellWords quotes a list of words as a single shell word.
+func shellWords(words []string) string {
+	return shellQuote(strings.Join(words, " "))
+}
+
+func writeBashFlagValues(w io.Writer, cc *complCmd) {
+	for _, cf := range cc.flags {
+		if cf.noValue || (!cf.files && !cf.dynamic && cf.values == nil) {
+			continue
+		}
+
+		patterns := []string{}
+		for _, name := range cf.names {
+			patterns = append(patterns, shellQuote(cc.path+" "+name))
+		}
+
+		switch {
+		case cf.files:
+			fmt.Fprintf(w, "        %s) COMPREPLY=($(compgen -f -- \"$cur\")); return ;;\n", strings.Join(patterns, "|"))
+		case cf.dynamic:
+			fmt.Fprintf(w, "        %s) COMPREPLY=(%s); return ;;\n", strings.Join(patterns, "|"), bashDynamic)
+		default:
+			fmt.Fprintf(w, "        %s) COMPREPLY=($(compgen -W %s -- \"$cur\")); return ;;\n",
+				strings.Join(patterns, "|"), shellWords(cf.values))
+		}
+	}
+}
+
+// bashDynamic asks the program for the candidates for the current word.
+const bashDynamic = `$("${COMP_WORDS[0]}" ` + completeCommand + ` "${COMP_WORDS[@]:1:COMP_CWORD}" 2>/dev/null)`
+
+func (cmd *Command) writeBashCompletion(w io.Writer) {
+	tree := cmd.completionTree(cmd.Name)
+
+	fmt.Fprintf(w, "# bash completion for %s\n\n", cmd.Name)
+	fmt.Fprintf(w, "%s() {\n", cmd.completionFunc())
+	fmt.Fprintln(w, `    local cur="${COMP_WORDS[COMP_CWORD]}" prev="${COMP_WORDS[COMP_CWORD-1]}"`)
+	fmt.Fprintf(w, "    local cmd=%s i\n\n", shellQuote(cmd.Name))
+
+	// find the command path from the words before the current one
+	fmt.Fprintln(w, `    for ((i = 1; i < COMP_CWORD; i++)); do`)
+	fmt.Fprintln(w, `        case "$cmd ${COMP_WORDS[i]}" in`)
+
+	for _, cc := range tree[1:] {
+		path := shellQuote(cc.path)
+		fmt.Fprintf(w, "            %s) cmd=%s ;;\n", path, path)
+	}
+
+	fmt.Fprintln(w, "        esac")
+	fmt.Fprintln(w, "    done")
+	fmt.Fprintln(w)
+
+	// complete the values of the flag before the current word
+	fmt.Fprintln(w, `    case "$cmd $prev" in`)
+
+	for _, cc := range tree {
+		writeBashFlagValues(w, cc)
+	}
+
+	fmt.Fprintln(w, "    esac")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, `    case "$cmd" in`)
+
+	for _, cc := range tree {
+		flagNames := []string{}
+		for i := range cc.flags {
+			flagNames = append(flagNames, cc.flags[i].names...)
+		}
+
+		fmt.Fprintf(w, "        %s)\n", shellQuote(cc.path))
+		fmt.Fprintln(w, `            if [[ "$cur" == -* ]]; then`)
+		fmt.Fprintf(w, "                COMPREPLY=($(compgen -W %s -- \"$cur\"))\n", shellWords(flagNames))
+		fmt.Fprintln(w, "            else")
+		fmt.Fprintf(w, "                COMPREPLY=($(compgen -W %s -- \"$cur\"))\n", shellWords(cc.subNames()))
+
+		if cc.files {
+			fmt.Fprintln(w, `                COMPREPLY+=($(compgen -f -- "$cur"))`)
+		}
+
+		if cc.dynamic {
+			fmt.Fprintf(w, "                COMPREPLY+=(%s)\n", bashDynamic)
+		}
+
+		fmt.Fprintln(w, "            fi ;;")
+	}
+
+	fmt.Fprintln(w, "    esac")
+	fmt.Fprintln(w, "}")
+	fmt.Fprintln(w)
+	fmt.Fprintf(w, "complete -F %s %s\n", cmd.completionFunc(), shellQuote(cmd.Name))
+}
+
+func (cmd *Command) writeZshCompletion(w io.Writer) {
+	// zsh can use bash completion functions through bashcompinit
+	fmt.Fprintf(w, "#compdef %s\n\n", cmd.Name)
+	fmt.Fprintln(w, "autoload -U +X bashcompinit && bashcompinit")
+	fmt.Fprintln(w)
+	cmd.writeBashCompletion(w)
+}
+
+// fishDynamic asks the program for the candidates for the current word.
+const fishDynamic = `((commandline -opc)[1] ` + completeCommand + ` (commandline -opc)[2..-1] (commandline -ct) 2>/dev/null)`
+
+func (cmd *Command) writeFishCompletion(w io.Writer) {
+	tree := cmd.completionTree(cmd.Name)
+	name := shellQuote(cmd.Name)
+	pathFunc := cmd.completionFunc() + "_path"
+
+	fmt.Fprintf(w, "# fish completion for %s\n\n", cmd.Name)
+
+	// find the command path from the words before the current one
+	fmt.Fprintf(w, "function %s\n", pathFunc)
+	fmt.Fprintf(w, "    set -l cmd %s\n", name)
+	fmt.Fprintln(w, "    for w in (commandline -opc)[2..-1]")
+	fmt.Fprintln(w, `        switch "$cmd $w"`)
+
+	for _, cc := range tree[1:] {
+		path := shellQuote(cc.path)
+		fmt.Fprintf(w, "            case %s\n                set cmd %s\n", path, path)
+	}
+
+	fmt.Fprintln(w, "        end")
+	fmt.Fprintln(w, "    end")
+	fmt.Fprintln(w, `    echo $cmd`)
+	fmt.Fprintln(w, "end")
+	fmt.Fprintln(w)
+	fmt.Fprintf(w, "complete -c %s -f\n", name)
+
+	for _, cc := range tree {
+		cond := shellQuote(fmt.Sprintf(`test (%s) = "%s"`, pathFunc, cc.path))
+
+		for _, sub := range cc.subs {
+			fmt.Fprintf(w, "complete -c %s -n %s -a %s -d %s\n",
+				name, cond, shellQuote(sub.Name), shellQuote(sub.Short))
+		}
+
+		for _, cf := range cc.flags {
+			opts := ""
+			if cf.long != "" {
+				opts += " -l " + shellQuote(cf.long)
+			}
+
+			if cf.short != "" {
+				opts += " -s " + shellQuote(cf.short)
+			}
+
+			switch {
+			case cf.noValue:
+			case cf.files:
+				opts += " -r -F"
+			case cf.values != nil:
+				opts += " -x -a " + shellWords(cf.values)
+			case cf.dynamic:
+				opts += " -x -a " + shellQuote(fishDynamic)
+			default:
+				opts += " -x"
+			}
+
+			fmt.Fprintf(w, "complete -c %s -n %s%s -d %s\n", name, cond, opts, shellQuote(cf.descr))
+		}
+
+		if cc.files {
+			fmt.Fprintf(w, "complete -c %s -n %s -F\n", name, cond)
+		}
+
+		if cc.dynamic {
+			fmt.Fprintf(w, "complete -c %s -n %s -a %s\n", name, cond, shellQuote(fishDynamic))
+		}
+	}
+}
+
+// WriteCompletion writes a completion script for the command tree, with cmd as
+// the top-level command, to w. The shell must be one of "bash", "zsh", and "fish".
+// The script completes subcommand names, flags, the options for Choice values,
+// and file names for InFile and OutFile values. Hidden commands are left out.
+func (cmd *Command) WriteCompletion(w io.Writer, shell string) error {
+	var buf bytes.Buffer
+
+	switch shell {
+	case "bash":
+		cmd.writeBashCompletion(&buf)
+	case "zsh":
+		cmd.writeZshCompletion(&buf)
+	case "fish":
+		cmd.writeFishCompletion(&buf)
+	default:
+		return interfaces.SpecErrorf("unknown shell %q, must be one of %s", shell, strings.Join(completionShells, ", "))
+	}
+
+	_, err := w.Write(buf.Bytes())
+
+	return err
+}
+
+// CompletionCommand returns a hidden command, named completion, that writes a
+// completion script for root to its output. Add it to root with AddSubcommands,
+// and users can then enable completion with, e.g., `source <(tool completion bash)`.
+func CompletionCommand(root *Command) *Command {
+	type completionArgs struct {
+		Shell Choice `pos:"shell" descr:"shell to generate a completion script for"`
+	}
+
+	var cmd *Command
+
+	cmd = NewCommand(CommandSpec{
+		Name:   "completion",
+		Short:  "write a shell completion script",
+		Long:   "Writes a completion script for " + root.Name + " for the given shell.",
+		Hidden: true,
+		Init: func() interface{} {
+			return &completionArgs{Shell: Choice{Options: completionShells}}
+		},
+		ActionError: func(i interface{}) error {
+			args, _ := i.(*completionArgs)
+			return root.WriteCompletion(cmd.Output(), args.Shell.Choice)
+		},
+	})
+
+	return cmd
+}
@@ -0,0 +1,122 @@
+package cli_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/mailund/cli"
+)
+
+func checkContains(t *testing.T, script string, parts ...string) {
+	t.Helper()
+
+	for _, part := range parts {
+		if !strings.Contains(script, part) {
+			t.Errorf("expected the script to contain %q:\n%s", part, script)
+		}
+	}
+}
+
+func TestBashCompletion(t *testing.T) {
+	root, out := toolTree(t)
+	if err := root.RunError([]string{"completion", "bash"}); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	script := out.String()
+	checkContains(t, script,
+		"complete -F __tool_complete tool",
+		`'tool calc add') cmd='tool calc add' ;;`,
+		`'tool --mode'|'tool -m') COMPREPLY=($(compgen -W 'fast slow' -- "$cur")); return ;;`,
+		`'tool --in'|'tool -i') COMPREPLY=($(compgen -f -- "$cur")); return ;;`,
+		`compgen -W '--help -h --mode -m --in -i --verbose -v'`,
+		`compgen -W calc -- "$cur"`,
+		`compgen -W add -- "$cur"`,
+		`COMPREPLY+=($(compgen -f -- "$cur"))`)
+
+	if strings.Contains(script, "secret") || strings.Contains(script, "tool completion") {
+		t.Errorf("hidden commands should not be completed:\n%s", script)
+	}
+}
+
+func TestZshCompletion(t *testing.T) {
+	root, out := toolTree(t)
+	if err := root.RunError([]string{"completion", "zsh"}); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if script := out.String(); !strings.HasPrefix(script, "#compdef tool\n") {
+		t.Errorf("unexpected zsh script:\n%s", script)
+	} else {
+		checkContains(t, script, "bashcompinit", "complete -F __tool_complete tool")
+	}
+}
+
+func TestFishCompletion(t *testing.T) {
+	root, out := toolTree(t)
+	if err := root.RunError([]string{"completion", "fish"}); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	script := out.String()
+	checkContains(t, script,
+		"function __tool_complete_path",
+		`complete -c tool -n 'test (__tool_complete_path) = "tool"' -a calc -d 'a calculator'`,
+		`-l mode -s m -x -a 'fast slow' -d 'the mode'`,
+		`-l in -s i -r -F -d 'input file'`,
+		`-l verbose -s v -d ''`,
+		`complete -c tool -n 'test (__tool_complete_path) = "tool calc add"' -F`)
+
+	if strings.Contains(script, "secret") {
+		t.Errorf("hidden commands should not be completed:\n%s", script)
+	}
+}
+
+func TestCompletionErrors(t *testing.T) {
+	root, _ := toolTree(t)
+	if err := root.WriteCompletion(new(strings.Builder), "cmd.exe"); err == nil {
+		t.Error("expected an error for an unknown shell")
+	}
+
+	if err := root.RunError([]string{"completion", "cmd.exe"}); err == nil {
+		t.Error("expected an error for an unknown shell")
+	}
+}
+
+func TestHiddenUsage(t *testing.T) {
+	root, out := toolTree(t)
+	if err := root.RunError([]string{"-h"}); err != cli.ErrHelp {
+		t.Fatalf("expected help, got %v", err)
+	}
+
+	if strings.Contains(out.String(), "completion") {
+		t.Errorf("hidden commands should not be in the usage:\n%s", out)
+	}
+}
+
+func TestAddSubcommands(t *testing.T) {
+	var ran bool
+
+	sub := cli.NewCommand(cli.CommandSpec{Name: "sub", Action: func(interface{}) { ran = true }})
+	cmd := cli.NewCommand(cli.CommandSpec{Name: "cmd"})
+
+	if err := cmd.AddSubcommands(sub); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if err := cmd.RunError([]string{"sub"}); err != nil || !ran {
+		t.Errorf("the added subcommand should run (err: %v)", err)
+	}
+
+	variadic := cli.NewCommand(cli.CommandSpec{
+		Name: "variadic",
+		Init: func() interface{} {
+			return new(struct {
+				Xs []string `pos:"xs"`
+			})
+		},
+	})
+	if err := variadic.AddSubcommands(sub); err == nil {
+		t.Error("a command with a variadic parameter cannot have subcommands")
+	}
+}
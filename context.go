@@ -0,0 +1,114 @@
+package cli
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/mailund/cli/interfaces"
+)
+
+// interruptStatus is the exit status when a second signal forces the
+// program to terminate (128 + SIGINT)
+const interruptStatus = 130
+
+type contextKey struct{}
+
+// commandContext is the information about a command invocation that we
+// store in the context passed to actions.
+type commandContext struct {
+	path  []string      // names of the commands leading to the current one
+	argvs []interface{} // the parsed argv of each command in path
+	cmd   *Command      // the instance of the current command
+}
+
+// withCommand returns a context for running an instance of a command with
+// the given path. The path must include the command's own name.
+func withCommand(ctx context.Context, path []string, cmd *Command) context.Context {
+	parent, _ := ctx.Value(contextKey{}).(*commandContext)
+
+	cc := &commandContext{path: path, cmd: cmd}
+	if parent != nil {
+		cc.argvs = append(cc.argvs, parent.argvs...)
+	}
+
+	cc.argvs = append(cc.argvs, cmd.argv)
+
+	return context.WithValue(ctx, contextKey{}, cc)
+}
+
+// CommandPath returns the names of the commands leading to the command
+// running with ctx, starting with the top-level command and ending with
+// the command itself. It returns nil if ctx doesn't come from a command.
+func CommandPath(ctx context.Context) []string {
+	if cc, ok := ctx.Value(contextKey{}).(*commandContext); ok {
+		return cc.path
+	}
+
+	return nil
+}
+
+// ParentArgv returns the parsed argv structs of the commands above the
+// command running with ctx, starting with the top-level command. The
+// argv for a command without an Init function is nil.
+func ParentArgv(ctx context.Context) []interface{} {
+	if cc, ok := ctx.Value(contextKey{}).(*commandContext); ok {
+		return cc.argvs[:len(cc.argvs)-1]
+	}
+
+	return nil
+}
+
+// Source reports where the flag or positional argument with the given name got
+// its value from, for the command running with ctx. It works as the Source method
+// on Command, but always reports on the run that ctx belongs to, also if the same
+// command runs in several goroutines. The second return value is false if ctx
+// doesn't come from a command or the command doesn't have an argument with
+// that name.
+func Source(ctx context.Context, name string) (interfaces.Provenance, bool) {
+	if cc, ok := ctx.Value(contextKey{}).(*commandContext); ok {
+		return cc.cmd.source(name)
+	}
+
+	return interfaces.Provenance{}, false
+}
+
+// IsSet reports whether the flag or positional argument with the given name was
+// explicitly given a value, for the command running with ctx.
+func IsSet(ctx context.Context, name string) bool {
+	prov, ok := Source(ctx, name)
+	return ok && prov.Source != interfaces.SourceDefault
+}
+
+// withSignals returns a context that is cancelled when the program receives
+// SIGINT or SIGTERM. A second signal terminates the program by calling exit.
+// Call the returned function to stop listening for signals.
+func withSignals(ctx context.Context, exit func(int)) (context.Context, func()) {
+	ctx, cancel := context.WithCancel(ctx)
+	sigs := make(chan os.Signal, 1)
+	done := make(chan struct{})
+
+	signal.Notify(sigs, os.Interrupt, syscall.SIGTERM)
+
+	go func() {
+		select {
+		case <-sigs:
+			cancel()
+		case <-done:
+			return
+		}
+
+		select {
+		case <-sigs:
+			exit(interruptStatus)
+		case <-done:
+		}
+	}()
+
+	return ctx, func() {
+		signal.Stop(sigs)
+		close(done)
+		cancel()
+	}
+}
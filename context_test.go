@@ -0,0 +1,139 @@
+package cli_test
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/mailund/cli"
+)
+
+type ctxKey struct{}
+
+func TestActionContext(t *testing.T) {
+	type ParentArgs struct {
+		X int `flag:"x"`
+	}
+
+	var (
+		path    []string
+		parents []interface{}
+		value   interface{}
+	)
+
+	sub := cli.NewCommand(cli.CommandSpec{
+		Name: "sub",
+		ActionContext: func(ctx context.Context, _ interface{}) error {
+			path, parents, value = cli.CommandPath(ctx), cli.ParentArgv(ctx), ctx.Value(ctxKey{})
+			return nil
+		},
+	})
+	cmd := cli.NewCommand(cli.CommandSpec{
+		Name:        "cmd",
+		Init:        func() interface{} { return new(ParentArgs) },
+		Subcommands: []*cli.Command{sub},
+	})
+
+	ctx := context.WithValue(context.Background(), ctxKey{}, "caller")
+	if err := cmd.RunErrorContext(ctx, []string{"-x", "42", "sub"}); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if !reflect.DeepEqual(path, []string{"cmd", "sub"}) {
+		t.Errorf("unexpected path: %v", path)
+	}
+
+	if len(parents) != 1 || parents[0].(*ParentArgs).X != 42 {
+		t.Errorf("unexpected parent argv: %v", parents)
+	}
+
+	if value != "caller" {
+		t.Errorf("the context should be derived from the caller's, got %v", value)
+	}
+
+	if cli.CommandPath(context.Background()) != nil || cli.ParentArgv(context.Background()) != nil {
+		t.Error("a context not from a command should not have a path or parent argv")
+	}
+}
+
+func interrupt(t *testing.T) {
+	t.Helper()
+
+	p, err := os.FindProcess(os.Getpid())
+	if err != nil {
+		t.Fatalf("cannot find own process: %s", err)
+	}
+
+	if err := p.Signal(os.Interrupt); err != nil {
+		t.Skipf("cannot send signals on this platform: %s", err)
+	}
+}
+
+func TestSignalCancellation(t *testing.T) {
+	const timeout = 5 * time.Second
+
+	status := make(chan int, 1)
+	cmd := cli.NewCommand(cli.CommandSpec{
+		Name: "cmd",
+		ActionContext: func(ctx context.Context, _ interface{}) error {
+			interrupt(t)
+
+			select {
+			case <-ctx.Done():
+			case <-time.After(timeout):
+				t.Fatal("the context was not cancelled")
+			}
+
+			interrupt(t)
+
+			select {
+			case code := <-status:
+				if code != 130 { //nolint:gomnd // exit status for interrupts
+					t.Errorf("unexpected exit status %d", code)
+				}
+			case <-time.After(timeout):
+				t.Fatal("a second signal should terminate the program")
+			}
+
+			return ctx.Err()
+		},
+	})
+	cmd.SetExitFunc(func(code int) { status <- code })
+
+	if err := cmd.RunError([]string{}); err != context.Canceled { //nolint:errorlint // we return ctx.Err() directly
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestSignalsWithPlainAction(t *testing.T) {
+	// Catch the signals ourselves, so the test survives them
+	sigs := make(chan os.Signal, 2)
+	signal.Notify(sigs, os.Interrupt)
+
+	defer signal.Stop(sigs)
+
+	status := make(chan int, 1)
+	cmd := cli.NewCommand(cli.CommandSpec{
+		Name: "cmd",
+		Action: func(interface{}) {
+			interrupt(t)
+			<-sigs
+			interrupt(t)
+			<-sigs
+
+			select {
+			case <-status:
+				t.Error("a plain action should leave signals to the program")
+			case <-time.After(100 * time.Millisecond):
+			}
+		},
+	})
+	cmd.SetExitFunc(func(code int) { status <- code })
+
+	if err := cmd.RunError([]string{}); err != nil {
+		t.Errorf("unexpected error: %s", err)
+	}
+}
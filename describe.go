@@ -0,0 +1,117 @@
+package cli
+
+import (
+	"encoding/json"
+
+	"github.com/mailund/cli/interfaces"
+	"github.com/mailund/cli/internal/flags"
+)
+
+// FlagDescription describes a flag in a CommandDescription.
+type FlagDescription struct {
+	Long        string   `json:"long,omitempty"`        // The long name, without dashes
+	Short       string   `json:"short,omitempty"`       // The short name, without the dash
+	Names       []string `json:"names"`                 // All the ways to write the flag, e.g. ["--mode", "-m"]
+	Type        string   `json:"type"`                  // Description of the value's type, e.g. "integer"
+	Default     string   `json:"default,omitempty"`     // The default value, as a string
+	Description string   `json:"description,omitempty"` // The flag's description
+	TakesValue  bool     `json:"takesValue"`            // Whether the flag takes its value from the next argument
+	Group       string   `json:"group,omitempty"`       // The heading the flag is shown under in usage, if any
+}
+
+// ParamDescription describes a positional argument in a CommandDescription.
+type ParamDescription struct {
+	Name        string `json:"name"`                  // The name of the argument
+	Type        string `json:"type,omitempty"`        // Description of the value's type, if it has one
+	Description string `json:"description,omitempty"` // The argument's description
+	Variadic    bool   `json:"variadic"`              // Whether the argument takes the remaining arguments
+	Min         int    `json:"min,omitempty"`         // The minimum number of arguments, for variadic arguments
+}
+
+// CommandDescription is a serialisable description of a command and its
+// subcommands, for tools that need to know a program's command-line interface.
+type CommandDescription struct {
+	Name        string                `json:"name"`                  // The command's name
+	Path        []string              `json:"path"`                  // Names of the commands leading to this one, including it
+	Short       string                `json:"short,omitempty"`       // The short description
+	Long        string                `json:"long,omitempty"`        // The long description
+	Category    string                `json:"category,omitempty"`    // The category the command is listed under in usage
+	Examples    []Example             `json:"examples,omitempty"`    // Examples of how to use the command
+	Flags       []FlagDescription     `json:"flags"`                 // The command's flags
+	Params      []ParamDescription    `json:"params"`                // The positional arguments
+	Subcommands []*CommandDescription `json:"subcommands,omitempty"` // The (visible) subcommands, in the order usage lists them
+}
+
+// visibleFlags returns the flags that are not hidden, in the order they were declared.
+func (cmd *Command) visibleFlags() []*flags.Flag {
+	fs := []*flags.Flag{}
+
+	for i := 0; i < cmd.flags.NFlags(); i++ {
+		if f := cmd.flags.Flag(i); !f.Hidden {
+			fs = append(fs, f)
+		}
+	}
+
+	return fs
+}
+
+func describeFlag(f *flags.Flag) FlagDescription {
+	return FlagDescription{
+		Long: f.Long, Short: f.Short, Names: f.Spellings(),
+		Type: f.ValueDescription(), Default: f.DefValue, Description: f.Desc,
+		TakesValue: f.TakesValue(), Group: f.Group,
+	}
+}
+
+func paramType(val interface{}) string {
+	if d, ok := val.(interfaces.FlagValueDescription); ok {
+		return d.FlagValueDescription()
+	}
+
+	return ""
+}
+
+func (cmd *Command) describe(path []string) *CommandDescription {
+	desc := &CommandDescription{
+		Name: cmd.Name, Path: path, Short: cmd.Short, Long: cmd.Long, Category: cmd.Category,
+		Examples: cmd.Examples,
+		Flags:    []FlagDescription{}, Params: []ParamDescription{},
+	}
+
+	for _, f := range cmd.visibleFlags() {
+		desc.Flags = append(desc.Flags, describeFlag(f))
+	}
+
+	for i := 0; i < cmd.params.NParams(); i++ {
+		p := cmd.params.Param(i)
+		desc.Params = append(desc.Params, ParamDescription{Name: p.Name, Type: paramType(p.Value), Description: p.Desc})
+	}
+
+	if vv := cmd.params.Variadic(); vv != nil {
+		desc.Params = append(desc.Params, ParamDescription{
+			Name: vv.Name, Type: paramType(vv.Value), Description: vv.Desc,
+			Variadic: true, Min: vv.Min,
+		})
+	}
+
+	for _, sub := range cmd.visibleSubcommands() {
+		desc.Subcommands = append(desc.Subcommands, sub.describe(subPath(path, sub.Name)))
+	}
+
+	return desc
+}
+
+// Describe returns a description of cmd and its (visible) subcommands. The
+// description can be serialised as JSON, and is what the hidden --help-json
+// flag prints.
+func (cmd *Command) Describe() *CommandDescription {
+	return cmd.describe(cmd.Path())
+}
+
+// writeDescription writes the command's description, as JSON, to its output.
+func (cmd *Command) writeDescription() error {
+	enc := json.NewEncoder(cmd.Output())
+	enc.SetIndent("", "  ")
+
+	return enc.Encode(cmd.Describe())
+}
@@ -0,0 +1,90 @@
+package cli_test
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/mailund/cli"
+)
+
+func TestDescribe(t *testing.T) {
+	root, _ := toolTree(t)
+	desc := root.Describe()
+
+	if desc.Name != "tool" || !reflect.DeepEqual(desc.Path, []string{"tool"}) {
+		t.Errorf("unexpected name and path: %s %v", desc.Name, desc.Path)
+	}
+
+	mode := cli.FlagDescription{
+		Long: "mode", Short: "m", Names: []string{"--mode", "-m"},
+		Type: "{fast,slow}", Default: "fast", Description: "the mode", TakesValue: true,
+	}
+	if len(desc.Flags) != 4 || !reflect.DeepEqual(desc.Flags[1], mode) {
+		t.Errorf("unexpected flags: %+v", desc.Flags)
+	}
+
+	if desc.Flags[3].Long != "verbose" || desc.Flags[3].TakesValue {
+		t.Errorf("unexpected verbose flag: %+v", desc.Flags[3])
+	}
+
+	if len(desc.Subcommands) != 1 || len(desc.Subcommands[0].Subcommands) != 1 {
+		t.Fatalf("expected only the visible subcommands: %+v", desc.Subcommands)
+	}
+
+	add := desc.Subcommands[0].Subcommands[0]
+	if !reflect.DeepEqual(add.Path, []string{"tool", "calc", "add"}) {
+		t.Errorf("unexpected path: %v", add.Path)
+	}
+
+	file := cli.ParamDescription{Name: "file", Type: "input file"}
+	if len(add.Params) != 1 || !reflect.DeepEqual(add.Params[0], file) {
+		t.Errorf("unexpected params: %+v", add.Params)
+	}
+
+	calc := desc.Subcommands[0]
+	if len(calc.Params) != 2 || !calc.Params[1].Variadic || calc.Params[1].Name != "..." {
+		t.Errorf("unexpected params: %+v", calc.Params)
+	}
+}
+
+func TestHelpJSON(t *testing.T) {
+	root, out := toolTree(t)
+
+	if err := root.RunError([]string{"--help-json"}); !errors.Is(err, cli.ErrHelp) {
+		t.Fatalf("expected a help error, got %v", err)
+	}
+
+	var desc cli.CommandDescription
+	if err := json.Unmarshal([]byte(out.String()), &desc); err != nil {
+		t.Fatalf("unexpected error: %s\n%s", err, out.String())
+	}
+
+	if !reflect.DeepEqual(&desc, root.Describe()) {
+		t.Errorf("unexpected description: %+v", desc)
+	}
+
+	out.Reset()
+
+	if err := root.RunError([]string{"calc", "add", "--help-json"}); !errors.Is(err, cli.ErrHelp) {
+		t.Fatalf("expected a help error, got %v", err)
+	}
+
+	desc = cli.CommandDescription{}
+	if err := json.Unmarshal([]byte(out.String()), &desc); err != nil {
+		t.Fatalf("unexpected error: %s\n%s", err, out.String())
+	}
+
+	if !reflect.DeepEqual(desc.Path, []string{"tool", "calc", "add"}) {
+		t.Errorf("unexpected path: %v", desc.Path)
+	}
+
+	out.Reset()
+	root.Usage()
+
+	if strings.Contains(out.String(), "help-json") {
+		t.Errorf("the --help-json flag should be hidden:\n%s", out.String())
+	}
+}
@@ -0,0 +1,138 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/mailund/cli/interfaces"
+)
+
+// ErrorHandling defines how Command.Run behaves if parsing or running a
+// command fails.
+type ErrorHandling int
+
+const (
+	// ContinueOnError makes Run report the error and return.
+	ContinueOnError ErrorHandling = iota
+	// ExitOnError makes Run report the error and call the command's exit
+	// function, which by default is os.Exit. The exit status is 0 for help,
+	// 2 for parse errors, and 1 for errors from actions, unless the error
+	// implements interfaces.ExitCoder, in which case it decides the status.
+	ExitOnError
+	// PanicOnError makes Run report the error and then panic with it.
+	PanicOnError
+)
+
+// ErrHelp is the error returned by RunError if the -h or --help flag is
+// given. The usage has already been printed when RunError returns it.
+var ErrHelp = errors.New("help requested")
+
+const (
+	// parseErrorStatus is the exit status for parse errors
+	parseErrorStatus = 2
+	// actionErrorStatus is the exit status for errors from actions
+	actionErrorStatus = 1
+)
+
+// SetErrorHandling sets how Run handles errors, for the command and all its
+// subcommands. The default is ExitOnError.
+func (cmd *Command) SetErrorHandling(handling ErrorHandling) {
+	for _, sub := range cmd.Subcommands {
+		sub.SetErrorHandling(handling)
+	}
+
+	cmd.errorHandling = handling
+}
+
+// ErrorHandling returns the error handling policy of the command.
+func (cmd *Command) ErrorHandling() ErrorHandling { return cmd.errorHandling }
+
+// SetExitFunc sets the function that Run calls to terminate the program
+// with ExitOnError, for the command and all its subcommands. The default
+// is os.Exit.
+func (cmd *Command) SetExitFunc(exit func(code int)) {
+	for _, sub := range cmd.Subcommands {
+		sub.SetExitFunc(exit)
+	}
+
+	cmd.exit = exit
+}
+
+func showHelp(usage func()) func() error {
+	return func() error {
+		usage()
+		return ErrHelp
+	}
+}
+
+// isHelp checks if err, or one of the errors in it if it is an interfaces.ParseErrors,
+// is a request for help
+func isHelp(err error) bool {
+	if errs, ok := err.(interfaces.ParseErrors); ok {
+		for _, e := range errs {
+			if errors.Is(e, ErrHelp) {
+				return true
+			}
+		}
+
+		return false
+	}
+
+	return errors.Is(err, ErrHelp)
+}
+
+// reportError prints err to the command's output. Parse errors, including
+// all the errors a command collects, are followed by the usage of the
+// (sub)command where they occurred.
+func (cmd *Command) reportError(failed *Command, err error) {
+	switch e := err.(type) {
+	case interfaces.ParseErrors:
+		for _, pe := range e {
+			fmt.Fprintf(cmd.out, "Error: %s.\n", pe)
+		}
+	case *interfaces.ParseError:
+		fmt.Fprintf(cmd.out, "Error: %s.\n", e)
+	default:
+		fmt.Fprintf(cmd.out, "Error: %s.\n", err)
+		return
+	}
+
+	fmt.Fprintf(cmd.out, "\n")
+	failed.Usage()
+}
+
+// exitCode returns the exit status that err should terminate the program with.
+func exitCode(err error) int {
+	var coder interfaces.ExitCoder
+	if errors.As(err, &coder) {
+		return coder.ExitCode()
+	}
+
+	switch err.(type) {
+	case *interfaces.ParseError, interfaces.ParseErrors:
+		return parseErrorStatus
+	default:
+		return actionErrorStatus
+	}
+}
+
+// handleError handles an error from running the command, according to the
+// command's error handling policy.
+func (cmd *Command) handleError(failed *Command, err error) {
+	status := 0
+
+	if !errors.Is(err, ErrHelp) {
+		cmd.reportError(failed, err)
+
+		status = exitCode(err)
+	}
+
+	switch cmd.errorHandling {
+	case ContinueOnError:
+		return
+	case ExitOnError:
+		cmd.exit(status)
+	case PanicOnError:
+		panic(err)
+	}
+}
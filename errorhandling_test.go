@@ -0,0 +1,101 @@
+package cli_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/mailund/cli"
+)
+
+type posXArgs struct {
+	X int `pos:"x"`
+}
+
+func TestErrHelp(t *testing.T) {
+	t.Parallel()
+
+	cmd := cli.NewCommand(cli.CommandSpec{Name: "cmd", Init: func() interface{} { return new(posXArgs) }})
+	menu, builder := withOutput(cli.NewMenu("menu", "", "", cmd))
+
+	if err := menu.RunError([]string{"cmd", "-h"}); err != cli.ErrHelp { //nolint:errorlint // we want the sentinel itself
+		t.Errorf("expected ErrHelp but got %v", err)
+	}
+
+	if !strings.HasPrefix(builder.String(), "Usage: menu cmd") {
+		t.Errorf("expected usage but got %s", builder.String())
+	}
+
+	menu.SetCollectErrors(true)
+
+	if err := menu.RunError([]string{"cmd", "-h", "--foo"}); !errors.Is(err, cli.ErrHelp) {
+		t.Errorf("expected ErrHelp but got %v", err)
+	}
+}
+
+func TestExitOnError(t *testing.T) {
+	t.Parallel()
+
+	cmd, builder := withOutput(cli.NewCommand(cli.CommandSpec{
+		Name: "cmd", Init: func() interface{} { return new(posXArgs) },
+	}))
+
+	status := -1
+	cmd.SetExitFunc(func(code int) { status = code })
+
+	if cmd.ErrorHandling() != cli.ExitOnError {
+		t.Error("expected ExitOnError to be the default")
+	}
+
+	cmd.Run([]string{"-h"})
+
+	if status != 0 {
+		t.Errorf("expected exit status 0 for help but got %d", status)
+	}
+
+	cmd.Run([]string{"foo"})
+
+	if status != 2 { //nolint:gomnd // 2 is the exit status for errors
+		t.Errorf("expected exit status 2 for errors but got %d", status)
+	}
+
+	if !strings.Contains(builder.String(), "Error: error parsing parameter x='foo'.\n\nUsage: cmd [flags] x") {
+		t.Errorf("unexpected output: %s", builder.String())
+	}
+}
+
+func TestContinueOnError(t *testing.T) {
+	t.Parallel()
+
+	cmd, builder := withOutput(cli.NewCommand(cli.CommandSpec{
+		Name: "cmd", Init: func() interface{} { return new(posXArgs) },
+	}))
+	cmd.SetErrorHandling(cli.ContinueOnError)
+	cmd.SetExitFunc(func(int) { t.Error("we should not exit") })
+
+	cmd.Run([]string{"foo"})
+	cmd.Run([]string{"-h"})
+
+	if msg := builder.String(); !strings.HasPrefix(msg, "Error: error parsing parameter x='foo'.\n\nUsage: cmd") {
+		t.Errorf("unexpected output: %s", msg)
+	}
+}
+
+func TestPanicOnError(t *testing.T) {
+	t.Parallel()
+
+	cmd, _ := withOutput(cli.NewCommand(cli.CommandSpec{
+		Name: "cmd", Init: func() interface{} { return new(posXArgs) },
+	}))
+	cmd.SetErrorHandling(cli.PanicOnError)
+
+	defer func() {
+		if err, ok := recover().(error); !ok || err.Error() != "error parsing parameter x='foo'" {
+			t.Errorf("unexpected panic: %v", err)
+		}
+	}()
+
+	cmd.Run([]string{"foo"})
+
+	t.Error("we should have panicked")
+}
@@ -0,0 +1,162 @@
+package cli_test
+
+import (
+	"errors"
+	"reflect"
+	"strconv"
+	"testing"
+
+	"github.com/mailund/cli"
+	"github.com/mailund/cli/interfaces"
+)
+
+var errPrepare = errors.New("prepare failed")
+
+type failingPrepare struct{}
+
+func (f *failingPrepare) Set(string) error    { return nil }
+func (f *failingPrepare) String() string      { return "" }
+func (f *failingPrepare) PrepareValue() error { return errPrepare }
+
+func errorsMenu() *cli.Command {
+	type Args struct {
+		N int            `flag:"n"`
+		P failingPrepare `flag:"p"`
+		X int            `pos:"x"`
+		Y int            `pos:"y"`
+	}
+
+	sub := cli.NewCommand(cli.CommandSpec{
+		Name: "sub",
+		Init: func() interface{} { return new(Args) },
+	})
+
+	return cli.NewMenu("menu", "", "", sub)
+}
+
+func TestParseErrorFields(t *testing.T) { //nolint:funlen // test tables are long
+	tests := []struct {
+		name     string
+		args     []string
+		expected interfaces.ParseError
+	}{
+		{
+			name: "unknown flag",
+			args: []string{"sub", "--foo"},
+			expected: interfaces.ParseError{
+				Kind: interfaces.KindUnknownFlag, Name: "foo", Index: 0, Token: "--foo",
+			},
+		},
+		{
+			name: "missing value",
+			args: []string{"sub", "-n"},
+			expected: interfaces.ParseError{
+				Kind: interfaces.KindMissingValue, Name: "n", Index: 0, Token: "-n",
+			},
+		},
+		{
+			name: "flag conversion",
+			args: []string{"sub", "-n", "foo", "1", "2"},
+			expected: interfaces.ParseError{
+				Kind: interfaces.KindConversion, Name: "n", Index: 1, Token: "foo",
+			},
+		},
+		{
+			name: "too few",
+			args: []string{"sub", "-n", "1", "1"},
+			expected: interfaces.ParseError{
+				Kind: interfaces.KindTooFewArgs, Index: -1,
+			},
+		},
+		{
+			name: "too many",
+			args: []string{"sub", "-n", "1", "1", "2", "3"},
+			expected: interfaces.ParseError{
+				Kind: interfaces.KindTooManyArgs, Index: 4, Token: "3",
+			},
+		},
+		{
+			name: "param conversion",
+			args: []string{"sub", "-n", "1", "1", "foo"},
+			expected: interfaces.ParseError{
+				Kind: interfaces.KindConversion, Name: "y", Index: 3, Token: "foo",
+			},
+		},
+		{
+			name: "prepare",
+			args: []string{"sub", "1", "2"},
+			expected: interfaces.ParseError{
+				Kind: interfaces.KindPrepare, Name: "p", Index: -1,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := errorsMenu().RunError(tt.args)
+
+			var perr *interfaces.ParseError
+			if !errors.As(err, &perr) {
+				t.Fatalf("expected a parse error but got %v", err)
+			}
+
+			if perr.Kind != tt.expected.Kind || perr.Name != tt.expected.Name ||
+				perr.Index != tt.expected.Index || perr.Token != tt.expected.Token {
+				t.Errorf("unexpected error %s: kind=%s name=%q index=%d token=%q",
+					perr, perr.Kind, perr.Name, perr.Index, perr.Token)
+			}
+
+			if !reflect.DeepEqual(perr.Path, []string{"menu", "sub"}) {
+				t.Errorf("unexpected path: %v", perr.Path)
+			}
+		})
+	}
+}
+
+func TestParseErrorUnwrap(t *testing.T) {
+	err := errorsMenu().RunError([]string{"sub", "1", "2"})
+	if !errors.Is(err, errPrepare) {
+		t.Errorf("expected the prepare error as the cause of %v", err)
+	}
+
+	err = errorsMenu().RunError([]string{"sub", "-n", "foo", "1", "2"})
+
+	var perr *interfaces.ParseError
+	if !errors.As(err, &perr) || perr.Unwrap() == nil {
+		t.Fatalf("expected a wrapped cause in %v", err)
+	}
+
+	if cause := perr.Unwrap().Error(); cause != `argument "foo" cannot be parsed as int` {
+		t.Errorf("unexpected cause: %s", cause)
+	}
+}
+
+func TestInvalidCommandError(t *testing.T) {
+	err := errorsMenu().RunError([]string{"foo"})
+
+	var perr *interfaces.ParseError
+	if !errors.As(err, &perr) {
+		t.Fatalf("expected a parse error but got %v", err)
+	}
+
+	if perr.Kind != interfaces.KindInvalidCommand || perr.Token != "foo" || perr.Index != 0 ||
+		!reflect.DeepEqual(perr.Path, []string{"menu"}) {
+		t.Errorf("unexpected error %s: kind=%s index=%d token=%q path=%v",
+			perr, perr.Kind, perr.Index, perr.Token, perr.Path)
+	}
+}
+
+func TestSpecErrorUnwrap(t *testing.T) {
+	type Args struct {
+		X []int `pos:"x" min:"foo"`
+	}
+
+	_, err := cli.NewCommandError(cli.CommandSpec{
+		Init: func() interface{} { return new(Args) },
+	})
+
+	var numErr *strconv.NumError
+	if !errors.As(err, &numErr) {
+		t.Errorf("expected a strconv error as the cause of %v", err)
+	}
+}
@@ -0,0 +1,170 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/mailund/cli/interfaces"
+)
+
+// Example is an example of how to use a command, shown in its usage and in
+// generated documentation.
+type Example struct {
+	Cmdline     string `json:"cmdline"`               // The full command line, starting with the top-level command
+	Description string `json:"description,omitempty"` // What the example does
+}
+
+// fileName is a stand-in for InFile and OutFile values when we verify
+// examples, so we don't open or create any files.
+type fileName string
+
+func (f *fileName) Set(x string) error { *f = fileName(x); return nil }
+func (f *fileName) String() string     { return string(*f) }
+
+// dryInstance returns an instance of the command that parses command lines
+// without printing usage, descriptions, or versions, and without opening files.
+func (cmd *Command) dryInstance() (*Command, error) {
+	inst := *cmd
+	inst.CommandSpec.Usage = func() {}
+
+	if err := inst.bind(inst.newArgv()); err != nil {
+		return nil, err
+	}
+
+	inst.quietBuiltins()
+
+	for i := 0; i < inst.flags.NFlags(); i++ {
+		if f := inst.flags.Flag(i); isFileValue(f.Value) {
+			f.Value = new(fileName)
+		}
+	}
+
+	for i := 0; i < inst.params.NParams(); i++ {
+		if p := inst.params.Param(i); isFileValue(p.Value) {
+			p.Value = new(fileName)
+		}
+	}
+
+	return &inst, nil
+}
+
+// splitArgs splits a command line into arguments the way a POSIX shell does,
+// handling quotes and backslashes but no expansions.
+func splitArgs(cmdline string) ([]string, error) {
+	var (
+		args    []string
+		arg     strings.Builder
+		inArg   bool
+		quote   rune
+		escaped bool
+	)
+
+	for _, r := range cmdline {
+		switch {
+		case escaped:
+			if quote == '"' && !strings.ContainsRune(`"\$`+"`", r) {
+				arg.WriteRune('\\') // inside double quotes, backslashes only escape some characters
+			}
+
+			arg.WriteRune(r)
+			escaped = false
+
+		case r == '\\' && quote != '\'':
+			escaped, inArg = true, true
+
+		case quote != 0 && r == quote:
+			quote = 0
+
+		case quote != 0:
+			arg.WriteRune(r)
+
+		case r == '\'' || r == '"':
+			quote, inArg = r, true
+
+		case r == ' ' || r == '\t' || r == '\n':
+			if inArg {
+				args = append(args, arg.String())
+				arg.Reset()
+			}
+
+			inArg = false
+
+		default:
+			arg.WriteRune(r)
+			inArg = true
+		}
+	}
+
+	if quote != 0 || escaped {
+		return nil, fmt.Errorf("unterminated quote or escape in %q", cmdline)
+	}
+
+	if inArg {
+		args = append(args, arg.String())
+	}
+
+	return args, nil
+}
+
+// verifyExample parses the example's command line against the command tree
+// rooted in root, and checks that it reaches the command with the given path.
+func verifyExample(root *Command, path []string, ex Example) error {
+	args, err := splitArgs(ex.Cmdline)
+	if err != nil {
+		return err
+	}
+
+	if len(args) == 0 || args[0] != root.Name {
+		return fmt.Errorf("the command line should start with %s", root.Name)
+	}
+
+	inv, _, err := root.parseInvocation(args[1:], (*Command).dryInstance)
+	if err != nil && !isHelp(err) {
+		return err
+	}
+
+	defer func() { _ = inv.Close() }()
+
+	if reached := inv.Path(); len(reached) < len(path) || strings.Join(reached[:len(path)], " ") != strings.Join(path, " ") {
+		return fmt.Errorf("the command line is for \"%s\"", strings.Join(reached, " "))
+	}
+
+	return nil
+}
+
+func (cmd *Command) verifyExamples(root *Command, path []string) []error {
+	var errs []error
+
+	for _, ex := range cmd.Examples {
+		if err := verifyExample(root, path, ex); err != nil {
+			serr := interfaces.SpecErrorf("example %q for %s: %s", ex.Cmdline, strings.Join(path, " "), err)
+			serr.Err = err
+
+			errs = append(errs, serr)
+		}
+	}
+
+	for _, sub := range cmd.Subcommands {
+		if cmd.subcommands[sub.Name] == sub {
+			errs = append(errs, sub.verifyExamples(root, subPath(path, sub.Name))...)
+		}
+	}
+
+	return errs
+}
+
+// VerifyExamples parses the command line of every example in the command tree,
+// with cmd as the top-level command, and returns an error for each example that
+// doesn't parse or doesn't reach the command it is an example for. It doesn't
+// run any actions or hooks, and it doesn't open files for InFile and OutFile
+// values, but other values are set as when parsing normally. It is meant for
+// tests, so examples do not go stale when commands change:
+//
+//	func TestExamples(t *testing.T) {
+//		for _, err := range root.VerifyExamples() {
+//			t.Error(err)
+//		}
+//	}
+func (cmd *Command) VerifyExamples() []error {
+	return cmd.verifyExamples(cmd, []string{cmd.Name})
+}
@@ -0,0 +1,128 @@
+package cli_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mailund/cli"
+)
+
+type copyArgs struct {
+	Verbose bool        `flag:"verbose" short:"v"`
+	Msg     string      `flag:"msg"`
+	In      cli.InFile  `pos:"in"`
+	Out     cli.OutFile `pos:"out"`
+}
+
+func TestVerifyExamples(t *testing.T) {
+	out := filepath.Join(t.TempDir(), "out.txt")
+
+	cp := cli.NewCommand(cli.CommandSpec{
+		Name:  "copy",
+		Short: "copy a file",
+		Examples: []cli.Example{
+			{Cmdline: "tool files copy -v no-such-file " + out, Description: "copy a file"},
+			{Cmdline: `tool files copy --msg "hello, world" 'in file' "out file"`},
+			{Cmdline: "tool files copy --help"},
+		},
+		Init:   func() interface{} { return new(copyArgs) },
+		Action: func(interface{}) { t.Error("examples should not run") },
+	})
+	root := cli.NewMenu("tool", "a tool", "", cli.NewMenu("files", "file commands", "", cp))
+
+	for _, err := range root.VerifyExamples() {
+		t.Error(err)
+	}
+
+	if _, err := os.Stat(out); !os.IsNotExist(err) {
+		t.Errorf("verifying examples should not create files")
+	}
+}
+
+func TestVerifyExamplesQuietly(t *testing.T) {
+	cp := cli.NewCommand(cli.CommandSpec{
+		Name:        "copy",
+		VersionFlag: true,
+		Examples: []cli.Example{
+			{Cmdline: "tool copy --help"},
+			{Cmdline: "tool copy --help-json"},
+			{Cmdline: "tool copy --version"},
+		},
+		Init: func() interface{} { return new(copyArgs) },
+	})
+	root, out := withOutput(cli.NewCommand(cli.CommandSpec{
+		Name: "tool", Version: "v1.0.0", VersionFlag: true, Subcommands: []*cli.Command{cp},
+	}))
+
+	for _, err := range root.VerifyExamples() {
+		t.Error(err)
+	}
+
+	if out.String() != "" {
+		t.Errorf("verifying examples should not print anything, got %q", out.String())
+	}
+}
+
+func TestVerifyExamplesErrors(t *testing.T) {
+	tests := []struct {
+		cmdline string
+		msg     string
+	}{
+		{"tool files copy --nope a b", "--nope"},
+		{"tool files copy a", "too few"},
+		{"other files copy a b", "should start with tool"},
+		{"tool -h", `is for "tool"`},
+		{"tool files copy 'a b", "unterminated"},
+	}
+
+	for _, tt := range tests {
+		cp := cli.NewCommand(cli.CommandSpec{
+			Name:     "copy",
+			Short:    "copy a file",
+			Examples: []cli.Example{{Cmdline: tt.cmdline}},
+			Init:     func() interface{} { return new(copyArgs) },
+			Action:   func(interface{}) { t.Error("examples should not run") },
+		})
+		root := cli.NewMenu("tool", "a tool", "", cli.NewMenu("files", "file commands", "", cp))
+
+		errs := root.VerifyExamples()
+		if len(errs) != 1 || !strings.Contains(errs[0].Error(), tt.msg) ||
+			!strings.Contains(errs[0].Error(), "tool files copy") {
+			t.Errorf("expected an error with %q for %q, got %v", tt.msg, tt.cmdline, errs)
+		}
+	}
+}
+
+func TestExamplesInUsage(t *testing.T) {
+	cp := cli.NewCommand(cli.CommandSpec{
+		Name:  "copy",
+		Short: "copy a file",
+		Examples: []cli.Example{
+			{Cmdline: "tool files copy a b", Description: "copy a to b"},
+			{Cmdline: "tool files copy -v a b"},
+		},
+		Init:   func() interface{} { return new(copyArgs) },
+		Action: func(interface{}) { t.Error("examples should not run") },
+	})
+	root, out := withOutput(cli.NewMenu("tool", "a tool", "", cli.NewMenu("files", "file commands", "", cp)))
+
+	_ = root.RunError([]string{"files", "copy", "-h"})
+
+	checkContains(t, out.String(), "\nExamples:\n  tool files copy a b\n\tcopy a to b\n  tool files copy -v a b\n")
+
+	dir := t.TempDir()
+	if err := root.WriteManPages(dir); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	page, _ := ioutil.ReadFile(filepath.Join(dir, "tool-files-copy.1"))
+
+	var doc strings.Builder
+	_ = root.WriteMarkdown(&doc)
+
+	checkContains(t, string(page), ".SH EXAMPLES\n.TP\n\\fBtool files copy a b\\fR\ncopy a to b\n")
+	checkContains(t, doc.String(), "### Examples\n\ncopy a to b\n\n```\ntool files copy a b\n```\n")
+}
@@ -11,6 +11,8 @@ import (
 type OutFile struct {
 	io.Writer
 	Fname string
+
+	opened bool // set when Open opened the file, so we know to close it
 }
 
 // Open opens a file given by name
@@ -20,7 +22,7 @@ func (o *OutFile) Open(fname string) error {
 		return interfaces.ParseErrorf("couldn't open file %s: %s", fname, err)
 	}
 
-	o.Writer = f
+	o.Writer, o.Fname, o.opened = f, fname, true
 
 	return nil
 }
@@ -52,15 +54,27 @@ func (o *OutFile) Validate(flag bool) error {
 	return interfaces.SpecErrorf("outfile does not have a valid default")
 }
 
-// Close implements the io.Closer interface by forwarding to the writer
+// Opened implements the Opener interface. It reports whether Open opened
+// the file, rather than the writer being given as a default.
+func (o *OutFile) Opened() bool {
+	return o.opened
+}
+
+// Close implements the io.Closer interface by closing the file if Open
+// opened it. Writers that were given as defaults, such as the standard
+// streams, belong to the caller and are never closed.
 func (o *OutFile) Close() error {
+	if !o.opened {
+		return nil
+	}
+
 	var err error
 
 	if closer, ok := o.Writer.(io.Closer); ok {
 		err = closer.Close()
 	}
 
-	o.Writer = nil
+	o.Writer, o.opened = nil, false
 
 	return err
 }
@@ -75,6 +89,12 @@ func (o *OutFile) PrepareValue() error {
 	return o.Open(o.Fname)
 }
 
+// FormatValue implements the ValueFormatter protocol. It returns the
+// file's name, which is empty if the file is a standard stream.
+func (o *OutFile) FormatValue() string {
+	return o.Fname
+}
+
 // FlagValueDescription implements the value protocol
 func (o *OutFile) FlagValueDescription() string {
 	return "output file"
@@ -84,6 +104,8 @@ func (o *OutFile) FlagValueDescription() string {
 type InFile struct {
 	io.Reader
 	Fname string
+
+	opened bool // set when Open opened the file, so we know to close it
 }
 
 // Open opens a file given by name
@@ -93,7 +115,7 @@ func (in *InFile) Open(fname string) error {
 		return interfaces.ParseErrorf("couldn't open file %s: %s", fname, err)
 	}
 
-	in.Reader = f
+	in.Reader, in.Fname, in.opened = f, fname, true
 
 	return nil
 }
@@ -123,15 +145,27 @@ func (in *InFile) Validate(flag bool) error {
 	return interfaces.SpecErrorf("infile does not have a valid default")
 }
 
-// Close implements the io.Closer interface by forwarding to the writer
+// Opened implements the Opener interface. It reports whether Open opened
+// the file, rather than the reader being given as a default.
+func (in *InFile) Opened() bool {
+	return in.opened
+}
+
+// Close implements the io.Closer interface by closing the file if Open
+// opened it. Readers that were given as defaults, such as the standard
+// input, belong to the caller and are never closed.
 func (in *InFile) Close() error {
+	if !in.opened {
+		return nil
+	}
+
 	var err error
 
 	if closer, ok := in.Reader.(io.Closer); ok {
 		err = closer.Close()
 	}
 
-	in.Reader = nil
+	in.Reader, in.opened = nil, false
 
 	return err
 }
@@ -146,6 +180,12 @@ func (in *InFile) PrepareValue() error {
 	return in.Open(in.Fname)
 }
 
+// FormatValue implements the ValueFormatter protocol. It returns the
+// file's name, which is empty if the file is the standard input.
+func (in *InFile) FormatValue() string {
+	return in.Fname
+}
+
 // FlagValueDescription implements the value protocol
 func (in *InFile) FlagValueDescription() string {
 	return "input file"
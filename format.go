@@ -0,0 +1,198 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/mailund/cli/interfaces"
+	"github.com/mailund/cli/internal/flags"
+	"github.com/mailund/cli/internal/vals"
+)
+
+// ErrCannotFormat is the error FormatArgs returns, wrapped with a description
+// of the argument, if a value cannot be written as a command line.
+var ErrCannotFormat = errors.New("cannot format the value")
+
+// formatValue returns the string that sets a value on the command line.
+func formatValue(val interface{}) (string, bool) {
+	switch v := val.(type) {
+	case vals.FuncValue:
+		return "", false // callbacks have no value to format
+	case interfaces.ValueFormatter:
+		return v.FormatValue(), true
+	case interfaces.FlagValue:
+		return v.String(), true
+	default:
+		return "", false
+	}
+}
+
+// formatFlag returns the arguments that set f to its current value, or nil
+// if the value is the same as def, the value of the flag in a fresh argv.
+func formatFlag(f, def *flags.Flag) ([]string, error) {
+	if nv, ok := f.Value.(interfaces.NoValueFlag); ok && nv.NoValueFlag() {
+		return nil, nil // flags without values have no state we can format
+	}
+
+	val, _ := formatValue(f.Value)
+	if defVal, _ := formatValue(def.Value); val == defVal {
+		return nil, nil
+	}
+
+	defFlag, hasDefault := f.Value.(interfaces.DefaultValueFlag)
+	isDefault := hasDefault && val == defFlag.DefaultValueFlag()
+
+	switch {
+	case f.Long != "" && isDefault:
+		return []string{"--" + f.Long}, nil
+	case f.Long != "":
+		return []string{"--" + f.Long + "=" + val}, nil
+	case isDefault:
+		return []string{"-" + f.Short}, nil
+	case !hasDefault && val != "" && val[0] != '-':
+		return []string{"-" + f.Short, val}, nil
+	default:
+		return nil, fmt.Errorf("%w %q for flag -%s", ErrCannotFormat, val, f.Short)
+	}
+}
+
+// formatParams returns the positional arguments of the command, not
+// counting those that select subcommands.
+func (cmd *Command) formatParams() ([]string, error) {
+	n := cmd.params.NParams()
+	if len(cmd.subcommands) > 0 {
+		n-- // the last parameter is the subcommand name
+	}
+
+	args := []string{}
+
+	for i := 0; i < n; i++ {
+		p := cmd.params.Param(i)
+
+		val, ok := formatValue(p.Value)
+		if !ok {
+			return nil, fmt.Errorf("%w of argument %s", ErrCannotFormat, p.Name)
+		}
+
+		args = append(args, val)
+	}
+
+	if vv := cmd.params.Variadic(); vv != nil && len(cmd.subcommands) == 0 {
+		f, ok := vv.Value.(interfaces.VariadicValueFormatter)
+		if !ok {
+			return nil, fmt.Errorf("%w of argument %s", ErrCannotFormat, vv.Name)
+		}
+
+		args = append(args, f.FormatValues()...)
+	}
+
+	return args, nil
+}
+
+// FormatArgs returns a command line that the command parses into the values in
+// argv, which should be a value of the type the command's Init function returns.
+// It is the inverse of parsing, so you can, for example, store a command line for
+// running later. Flags are only included if their value differs from the value in
+// a fresh argv from Init, and flags that are callbacks are left out. If argv holds
+// values that cannot be written as a command line, FormatArgs returns an error.
+// For a command with subcommands, FormatArgs only returns the command's own
+// arguments; use Invocation.FormatArgs to format a whole command path.
+func (cmd *Command) FormatArgs(argv interface{}) ([]string, error) {
+	inst, err := cmd.instanceFor(argv)
+	if err != nil {
+		return nil, err
+	}
+
+	defaults, err := cmd.instance()
+	if err != nil {
+		return nil, err
+	}
+
+	args := []string{}
+
+	for i := 0; i < inst.flags.NFlags(); i++ {
+		fargs, err := formatFlag(inst.flags.Flag(i), defaults.flags.Flag(i))
+		if err != nil {
+			return nil, err
+		}
+
+		args = append(args, fargs...)
+	}
+
+	pargs, err := inst.formatParams()
+	if err != nil {
+		return nil, err
+	}
+
+	// Positional arguments that look like flags must come after a "--"
+	for _, arg := range pargs {
+		if len(arg) > 1 && arg[0] == '-' {
+			args = append(args, "--")
+			break
+		}
+	}
+
+	return append(args, pargs...), nil
+}
+
+// FormatArgs returns a command line that reproduces the invocation, with the
+// arguments for each command, as formatted by Command.FormatArgs, followed
+// by the name of the next subcommand.
+func (inv *Invocation) FormatArgs() ([]string, error) {
+	args := []string{}
+
+	for i, lvl := range inv.Levels {
+		largs, err := lvl.Command.FormatArgs(lvl.Argv)
+		if err != nil {
+			return nil, err
+		}
+
+		args = append(args, largs...)
+
+		if i+1 < len(inv.Levels) {
+			args = append(args, inv.Levels[i+1].Command.Name)
+		}
+	}
+
+	return args, nil
+}
+
+// isShellSafe reports whether a string can be used as a shell word without
+// quoting.
+func isShellSafe(s string) bool {
+	if s == "" {
+		return false
+	}
+
+	for _, r := range s {
+		switch {
+		case 'a' <= r && r <= 'z', 'A' <= r && r <= 'Z', '0' <= r && r <= '9':
+		case strings.ContainsRune("-_./:=,+@%", r):
+		default:
+			return false
+		}
+	}
+
+	return true
+}
+
+// shellQuote quotes s, if needed, so a POSIX shell sees it as a single word.
+func shellQuote(s string) string {
+	if isShellSafe(s) {
+		return s
+	}
+
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// QuoteArgs joins args into a string that a POSIX shell splits back into
+// args, quoting the arguments that need it.
+func QuoteArgs(args []string) string {
+	quoted := make([]string, len(args))
+	for i, arg := range args {
+		quoted[i] = shellQuote(arg)
+	}
+
+	return strings.Join(quoted, " ")
+}
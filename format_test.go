@@ -0,0 +1,268 @@
+package cli_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/mailund/cli"
+)
+
+// roundTrip formats argv with a command whose Init returns a zero value of
+// argv's type, parses the result, and returns the formatted arguments and the
+// parsed argv.
+func roundTrip(t *testing.T, argv interface{}) ([]string, interface{}) {
+	t.Helper()
+
+	typ := reflect.TypeOf(argv).Elem()
+	cmd := cli.NewCommand(cli.CommandSpec{
+		Name: "cmd",
+		Init: func() interface{} { return reflect.New(typ).Interface() },
+	})
+
+	args, err := cmd.FormatArgs(argv)
+	if err != nil {
+		t.Fatalf("unexpected error formatting %v: %s", argv, err)
+	}
+
+	inv, err := cmd.Parse(args)
+	if err != nil {
+		t.Fatalf("unexpected error parsing %v: %s", args, err)
+	}
+	defer inv.Close()
+
+	return args, inv.Leaf().Argv
+}
+
+func TestFormatRoundTrip(t *testing.T) {
+	type Flags struct {
+		S    string     `flag:"s"`
+		B    bool       `flag:"b"`
+		I    int        `flag:"i"`
+		I8   int8       `flag:"i8"`
+		I16  int16      `flag:"i16"`
+		I32  int32      `flag:"i32"`
+		I64  int64      `flag:"i64"`
+		U    uint       `flag:"u"`
+		U8   uint8      `flag:"u8"`
+		U16  uint16     `flag:"u16"`
+		U32  uint32     `flag:"u32"`
+		U64  uint64     `flag:"u64"`
+		F32  float32    `flag:"f32"`
+		F64  float64    `flag:"f64"`
+		C64  complex64  `flag:"c64"`
+		C128 complex128 `flag:"c128"`
+		X    int        `short:"x" flag:""`
+		Pos  string     `pos:"pos"`
+	}
+
+	type (
+		Strings struct {
+			Xs []string `pos:"xs"`
+		}
+		Bools struct {
+			Xs []bool `pos:"xs"`
+		}
+		Ints struct {
+			Xs []int `pos:"xs"`
+		}
+		Int8s struct {
+			Xs []int8 `pos:"xs"`
+		}
+		Int16s struct {
+			Xs []int16 `pos:"xs"`
+		}
+		Int32s struct {
+			Xs []int32 `pos:"xs"`
+		}
+		Int64s struct {
+			Xs []int64 `pos:"xs"`
+		}
+		Uints struct {
+			Xs []uint `pos:"xs"`
+		}
+		Uint8s struct {
+			Xs []uint8 `pos:"xs"`
+		}
+		Uint16s struct {
+			Xs []uint16 `pos:"xs"`
+		}
+		Uint32s struct {
+			Xs []uint32 `pos:"xs"`
+		}
+		Uint64s struct {
+			Xs []uint64 `pos:"xs"`
+		}
+		Float32s struct {
+			Xs []float32 `pos:"xs"`
+		}
+		Float64s struct {
+			Xs []float64 `pos:"xs"`
+		}
+		Complex64s struct {
+			Xs []complex64 `pos:"xs"`
+		}
+		Complex128s struct {
+			Xs []complex128 `pos:"xs"`
+		}
+	)
+
+	tests := []struct {
+		name string
+		argv interface{}
+	}{
+		{"zero flags", &Flags{Pos: "foo"}},
+		{"flags", &Flags{
+			S: "hello, world", B: true, I: -1, I8: -8, I16: 16, I32: -32, I64: 64,
+			U: 1, U8: 8, U16: 16, U32: 32, U64: 64, F32: 0.1, F64: -3.14,
+			C64: 1 + 2i, C128: -1.5 - 2i, X: 42, Pos: "-pos",
+		}},
+		{"empty string", &Flags{S: "", Pos: ""}},
+		{"strings", &Strings{Xs: []string{"a b", "-c", "'d'"}}},
+		{"bools", &Bools{Xs: []bool{true, false}}},
+		{"ints", &Ints{Xs: []int{-1, 2}}},
+		{"int8s", &Int8s{Xs: []int8{-1, 2}}},
+		{"int16s", &Int16s{Xs: []int16{-1, 2}}},
+		{"int32s", &Int32s{Xs: []int32{-1, 2}}},
+		{"int64s", &Int64s{Xs: []int64{-1, 2}}},
+		{"uints", &Uints{Xs: []uint{1, 2}}},
+		{"uint8s", &Uint8s{Xs: []uint8{1, 2}}},
+		{"uint16s", &Uint16s{Xs: []uint16{1, 2}}},
+		{"uint32s", &Uint32s{Xs: []uint32{1, 2}}},
+		{"uint64s", &Uint64s{Xs: []uint64{1, 2}}},
+		{"float32s", &Float32s{Xs: []float32{0.1, -2}}},
+		{"float64s", &Float64s{Xs: []float64{0.1, -2}}},
+		{"complex64s", &Complex64s{Xs: []complex64{1 + 1i, -2}}},
+		{"complex128s", &Complex128s{Xs: []complex128{1 + 1i, -2}}},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			args, parsed := roundTrip(t, tt.argv)
+			if !reflect.DeepEqual(parsed, tt.argv) {
+				t.Errorf("%v parsed as %v, expected %v", args, parsed, tt.argv)
+			}
+		})
+	}
+}
+
+func TestFormatArgs(t *testing.T) {
+	type Args struct {
+		N       int        `flag:"n"`
+		Verbose bool       `flag:"verbose" short:"v"`
+		Quiet   bool       `flag:"quiet"`
+		Mode    cli.Choice `flag:"mode"`
+		X       int        `short:"x" flag:""`
+		Pos     string     `pos:"pos"`
+	}
+
+	cmd := cli.NewCommand(cli.CommandSpec{
+		Name: "cmd",
+		Init: func() interface{} {
+			return &Args{N: 42, Quiet: true, Mode: cli.Choice{Choice: "a", Options: []string{"a", "b"}}}
+		},
+	})
+
+	tests := []struct {
+		name     string
+		argv     *Args
+		expected []string
+	}{
+		{"defaults", &Args{N: 42, Quiet: true, Mode: cli.Choice{Choice: "a"}, Pos: "p"}, []string{"p"}},
+		{"values", &Args{N: 1, Verbose: true, Mode: cli.Choice{Choice: "b"}, X: 2, Pos: "p"},
+			[]string{"--n=1", "--verbose", "--quiet=false", "--mode=b", "-x", "2", "p"}},
+		{"dash", &Args{N: -1, Quiet: true, Mode: cli.Choice{Choice: "a"}, Pos: "-p"},
+			[]string{"--n=-1", "--", "-p"}},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			args, err := cmd.FormatArgs(tt.argv)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			if !reflect.DeepEqual(args, tt.expected) {
+				t.Errorf("got %q, expected %q", args, tt.expected)
+			}
+		})
+	}
+
+	if _, err := cmd.FormatArgs(&Args{X: -1}); !errors.Is(err, cli.ErrCannotFormat) {
+		t.Errorf("expected a format error for a negative short flag value, got %v", err)
+	}
+}
+
+func TestFormatCallbacks(t *testing.T) {
+	type Args struct {
+		F func(string) error `flag:"f"`
+		P func(string) error `pos:"p"`
+	}
+
+	cb := func(string) error { return nil }
+	cmd := cli.NewCommand(cli.CommandSpec{
+		Name: "cmd",
+		Init: func() interface{} { return &Args{F: cb, P: cb} },
+	})
+
+	if _, err := cmd.FormatArgs(&Args{F: cb, P: cb}); !errors.Is(err, cli.ErrCannotFormat) {
+		t.Errorf("expected a format error for a callback argument, got %v", err)
+	}
+}
+
+func TestFormatInvocation(t *testing.T) {
+	type (
+		CmdArgs struct {
+			V bool `flag:"v"`
+		}
+		SubArgs struct {
+			Name string   `flag:"name"`
+			Xs   []string `pos:"xs"`
+		}
+	)
+
+	sub := cli.NewCommand(cli.CommandSpec{
+		Name: "sub",
+		Init: func() interface{} { return new(SubArgs) },
+	})
+	cmd := cli.NewCommand(cli.CommandSpec{
+		Name:        "cmd",
+		Init:        func() interface{} { return new(CmdArgs) },
+		Subcommands: []*cli.Command{sub},
+	})
+
+	inv, err := cmd.Parse([]string{"-v", "sub", "--name", "foo bar", "x", "y"})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	args, err := inv.FormatArgs()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	expected := []string{"--v", "sub", "--name=foo bar", "x", "y"}
+	if !reflect.DeepEqual(args, expected) {
+		t.Errorf("got %q, expected %q", args, expected)
+	}
+}
+
+func TestQuoteArgs(t *testing.T) {
+	tests := []struct {
+		args     []string
+		expected string
+	}{
+		{[]string{}, ""},
+		{[]string{"--n=1", "foo/bar.txt"}, "--n=1 foo/bar.txt"},
+		{[]string{"", "a b", "it's"}, `'' 'a b' 'it'\''s'`},
+		{[]string{"$HOME", "*"}, `'$HOME' '*'`},
+	}
+
+	for _, tt := range tests {
+		if quoted := cli.QuoteArgs(tt.args); quoted != tt.expected {
+			t.Errorf("QuoteArgs(%q) = %s, expected %s", tt.args, quoted, tt.expected)
+		}
+	}
+}
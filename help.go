@@ -0,0 +1,89 @@
+package cli
+
+import (
+	"strings"
+
+	"github.com/mailund/cli/interfaces"
+)
+
+// helpCommand is the name of the command from HelpCommand.
+const helpCommand = "help"
+
+// usageTree prints usage for cmd and all its (visible) subcommands.
+func (cmd *Command) usageTree() {
+	cmd.Usage()
+
+	for _, sub := range cmd.visibleSubcommands() {
+		sub.usageTree()
+	}
+}
+
+// helpArgs are the arguments to the command from HelpCommand.
+type helpArgs struct {
+	All  bool     `flag:"all" descr:"show help for all subcommands as well"`
+	Path []string `pos:"command" descr:"the command to show help for" complete:"Complete"`
+	root *Command
+}
+
+// lookup returns the command that the path leads to from the root.
+func (args *helpArgs) lookup(path []string) (*Command, error) {
+	cmd := args.root
+
+	for _, name := range path {
+		sub, ok := cmd.subcommands[name]
+		if !ok {
+			return nil, interfaces.ParseErrorf("unknown command %s", strings.Join(subPath(cmd.Path(), name), " "))
+		}
+
+		cmd = sub
+	}
+
+	return cmd, nil
+}
+
+// Complete completes the names of the subcommands of the command given so far.
+func (args *helpArgs) Complete(prefix string) []string {
+	cmd, err := args.lookup(args.Path)
+	if err != nil {
+		return nil
+	}
+
+	names := []string{}
+	for _, sub := range cmd.visibleSubcommands() {
+		names = append(names, sub.Name)
+	}
+
+	return withPrefix(names, prefix)
+}
+
+// HelpCommand returns a command, named help, that prints usage information for
+// root or for the subcommand of root that its arguments name, so `tool help calc add`
+// shows the same as `tool calc add -h`. With the flag --all, it shows usage for
+// the command and all of its subcommands. The command is hidden, so it doesn't
+// change the usage of the commands it is added to. Menus made with NewMenu get a help
+// command automatically; for other commands, add it with AddSubcommands.
+func HelpCommand(root *Command) *Command {
+	return NewCommand(CommandSpec{
+		Name:   helpCommand,
+		Short:  "show help for a command",
+		Long:   "Shows help for " + root.Name + " or for one of its commands.",
+		Hidden: true,
+		Init:   func() interface{} { return &helpArgs{root: root} },
+		ActionError: func(i interface{}) error {
+			args, _ := i.(*helpArgs)
+
+			cmd, err := args.lookup(args.Path)
+			if err != nil {
+				return err
+			}
+
+			if args.All {
+				cmd.usageTree()
+			} else {
+				cmd.Usage()
+			}
+
+			return nil
+		},
+	})
+}
@@ -0,0 +1,76 @@
+package cli_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/mailund/cli"
+)
+
+func TestHelpCommand(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		contains []string
+		missing  []string
+	}{
+		{"root", []string{"help"}, []string{"Usage: tool [flags] cmd ...\n"}, []string{"Usage: tool calc"}},
+		{"path", []string{"help", "calc", "add"}, []string{"Usage: tool calc add [flags]"}, []string{"Usage: tool calc [flags]"}},
+		{"all", []string{"help", "--all"}, []string{
+			"Usage: tool [flags] cmd ...\n", "Usage: tool calc [flags] cmd ...\n",
+			"Usage: tool calc add [flags] file",
+		}, []string{
+			"Usage: tool help", "Usage: tool calc help", "Usage: tool calc secret", "Usage: tool completion",
+		}},
+		{"all below", []string{"help", "--all", "calc"}, []string{"Usage: tool calc add [flags]"}, []string{"Usage: tool [flags]"}},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			root, out := toolTree(t)
+			if err := root.RunError(tt.args); err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			checkContains(t, out.String(), tt.contains...)
+
+			for _, x := range tt.missing {
+				if strings.Contains(out.String(), x) {
+					t.Errorf("did not expect %q in:\n%s", x, out.String())
+				}
+			}
+		})
+	}
+}
+
+func TestHelpCommandErrors(t *testing.T) {
+	root, _ := toolTree(t)
+
+	err := root.RunError([]string{"help", "calc", "mul"})
+	if err == nil || !strings.Contains(err.Error(), "unknown command tool calc mul") {
+		t.Errorf("expected an unknown command error, got %v", err)
+	}
+}
+
+func TestHelpCommandCompletion(t *testing.T) {
+	root, out := toolTree(t)
+
+	if err := root.RunError([]string{"__complete", "help", "calc", ""}); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if out.String() != "add\n" {
+		t.Errorf("unexpected completions: %q", out.String())
+	}
+}
+
+func TestHelpCommandNotReplaced(t *testing.T) {
+	called := false
+	help := cli.NewCommand(cli.CommandSpec{Name: "help", Action: func(interface{}) { called = true }})
+	root := cli.NewMenu("tool", "", "", help)
+
+	if err := root.RunError([]string{"help"}); err != nil || !called {
+		t.Errorf("expected the menu's own help command to run: %v", err)
+	}
+}
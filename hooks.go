@@ -0,0 +1,56 @@
+package cli
+
+import "context"
+
+// Hook is the type of the lifecycle hooks in a CommandSpec. A hook gets the
+// context the command runs in and the parsed argv of the command it belongs to.
+type Hook func(ctx context.Context, argv interface{}) error
+
+// callHook calls hook with the command's argv, if the hook isn't nil.
+func (cmd *Command) callHook(ctx context.Context, hook Hook) error {
+	if hook == nil {
+		return nil
+	}
+
+	return hook(ctx, cmd.argv)
+}
+
+// persistentPreRun calls the persistent pre-run hooks for the commands in chain,
+// outermost first. The chain holds the commands leading to the command that
+// handles the command line, including that command itself.
+func persistentPreRun(ctx context.Context, chain []*Command) error {
+	for _, c := range chain {
+		if err := c.callHook(ctx, c.PersistentPreRun); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// persistentPostRun calls the persistent post-run hooks for the commands in
+// chain, innermost first.
+func persistentPostRun(ctx context.Context, chain []*Command) error {
+	for i := len(chain) - 1; i >= 0; i-- {
+		if err := chain[i].callHook(ctx, chain[i].PersistentPostRun); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// persistentFinally calls the persistent finally hooks for the commands in
+// chain, innermost first. All the hooks are called, even if some fail, and
+// the first error is returned.
+func persistentFinally(ctx context.Context, chain []*Command) error {
+	var err error
+
+	for i := len(chain) - 1; i >= 0; i-- {
+		if herr := chain[i].callHook(ctx, chain[i].PersistentFinally); herr != nil && err == nil {
+			err = herr
+		}
+	}
+
+	return err
+}
@@ -0,0 +1,206 @@
+package cli_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/mailund/cli"
+)
+
+// hookTrace records hook invocations in the order they happen.
+type hookTrace []string
+
+func (tr *hookTrace) hook(name string, err error) cli.Hook {
+	return func(context.Context, interface{}) error {
+		*tr = append(*tr, name)
+		return err
+	}
+}
+
+func (tr *hookTrace) action(name string, err error) func(interface{}) error {
+	return func(interface{}) error {
+		*tr = append(*tr, name)
+		return err
+	}
+}
+
+func TestHookOrder(t *testing.T) {
+	actionErr := errors.New("action failed")
+
+	tests := []struct {
+		name     string
+		args     []string
+		subErr   error
+		parseErr bool
+		expected hookTrace
+	}{
+		{"success", []string{"sub"}, nil, false, hookTrace{
+			"cmd pre", "cmd action",
+			"cmd persistent pre", "sub persistent pre", "sub pre", "sub action",
+			"sub post", "sub persistent post", "cmd persistent post",
+			"sub finally", "sub persistent finally", "cmd persistent finally",
+			"cmd post", "cmd finally",
+		}},
+		{"action error", []string{"sub"}, actionErr, false, hookTrace{
+			"cmd pre", "cmd action",
+			"cmd persistent pre", "sub persistent pre", "sub pre", "sub action",
+			"sub finally", "sub persistent finally", "cmd persistent finally",
+			"cmd finally",
+		}},
+		{"parse error", []string{"-x"}, nil, true, nil},
+	}
+
+	for _, tt := range tests {
+		var tr hookTrace
+
+		sub := cli.NewCommand(cli.CommandSpec{
+			Name:              "sub",
+			PreRun:            tr.hook("sub pre", nil),
+			ActionError:       tr.action("sub action", tt.subErr),
+			PostRun:           tr.hook("sub post", nil),
+			Finally:           tr.hook("sub finally", nil),
+			PersistentPreRun:  tr.hook("sub persistent pre", nil),
+			PersistentPostRun: tr.hook("sub persistent post", nil),
+			PersistentFinally: tr.hook("sub persistent finally", nil),
+		})
+		cmd := cli.NewCommand(cli.CommandSpec{
+			Name:              "cmd",
+			PreRun:            tr.hook("cmd pre", nil),
+			ActionError:       tr.action("cmd action", nil),
+			PostRun:           tr.hook("cmd post", nil),
+			Finally:           tr.hook("cmd finally", nil),
+			PersistentPreRun:  tr.hook("cmd persistent pre", nil),
+			PersistentPostRun: tr.hook("cmd persistent post", nil),
+			PersistentFinally: tr.hook("cmd persistent finally", nil),
+			Subcommands:       []*cli.Command{sub},
+		})
+		cmd.SetOutput(new(strings.Builder))
+
+		err := cmd.RunError(tt.args)
+		if tt.parseErr && err == nil {
+			t.Errorf("%s: expected a parse error", tt.name)
+		} else if !tt.parseErr && !errors.Is(err, tt.subErr) {
+			t.Errorf("%s: unexpected error: %v", tt.name, err)
+		}
+
+		if !reflect.DeepEqual(tr, tt.expected) {
+			t.Errorf("%s: unexpected hook order:\n%v\nexpected:\n%v", tt.name, tr, tt.expected)
+		}
+	}
+}
+
+func TestPersistentHooksRunOnce(t *testing.T) {
+	type RootArgs struct {
+		X int `flag:"x"`
+	}
+
+	var (
+		tr    hookTrace
+		argvs []interface{}
+	)
+
+	record := func(name string) cli.Hook {
+		return func(_ context.Context, argv interface{}) error {
+			tr, argvs = append(tr, name), append(argvs, argv)
+			return nil
+		}
+	}
+
+	leaf := cli.NewCommand(cli.CommandSpec{Name: "leaf"})
+	mid := cli.NewCommand(cli.CommandSpec{Name: "mid", Subcommands: []*cli.Command{leaf}})
+	root := cli.NewCommand(cli.CommandSpec{
+		Name:              "root",
+		Init:              func() interface{} { return new(RootArgs) },
+		PersistentPreRun:  record("pre"),
+		PersistentPostRun: record("post"),
+		PersistentFinally: record("finally"),
+		Subcommands:       []*cli.Command{mid},
+	})
+
+	if err := root.RunError([]string{"-x", "42", "mid", "leaf"}); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if !reflect.DeepEqual(tr, hookTrace{"pre", "post", "finally"}) {
+		t.Errorf("persistent hooks should run once, got %v", tr)
+	}
+
+	for _, argv := range argvs {
+		if a, ok := argv.(*RootArgs); !ok || a.X != 42 {
+			t.Errorf("persistent hooks should get the argv of their own command, got %v", argv)
+		}
+	}
+}
+
+func TestPreRunError(t *testing.T) {
+	var tr hookTrace
+
+	preErr := errors.New("pre failed")
+	cmd := cli.NewCommand(cli.CommandSpec{
+		Name:        "cmd",
+		PreRun:      tr.hook("pre", preErr),
+		ActionError: tr.action("action", nil),
+		Finally:     tr.hook("finally", nil),
+	})
+
+	if err := cmd.RunError([]string{}); !errors.Is(err, preErr) {
+		t.Fatalf("expected the pre-run error, got %v", err)
+	}
+
+	if !reflect.DeepEqual(tr, hookTrace{"pre", "finally"}) {
+		t.Errorf("unexpected hook order: %v", tr)
+	}
+}
+
+func TestFinallyError(t *testing.T) {
+	var tr hookTrace
+
+	actionErr, finallyErr := errors.New("action failed"), errors.New("finally failed")
+	cmd := cli.NewCommand(cli.CommandSpec{
+		Name:        "cmd",
+		ActionError: tr.action("action", nil),
+		Finally:     tr.hook("finally", finallyErr),
+	})
+
+	if err := cmd.RunError([]string{}); !errors.Is(err, finallyErr) {
+		t.Errorf("expected the finally error, got %v", err)
+	}
+
+	// An earlier error takes precedence over errors from Finally
+	cmd = cli.NewCommand(cli.CommandSpec{
+		Name:        "cmd",
+		ActionError: tr.action("action", actionErr),
+		Finally:     tr.hook("finally", finallyErr),
+	})
+
+	if err := cmd.RunError([]string{}); !errors.Is(err, actionErr) {
+		t.Errorf("expected the action error, got %v", err)
+	}
+}
+
+func TestFinallyOnPanic(t *testing.T) {
+	var tr hookTrace
+
+	cmd := cli.NewCommand(cli.CommandSpec{
+		Name:    "cmd",
+		Action:  func(interface{}) { panic("action panicked") },
+		Finally: tr.hook("finally", nil),
+	})
+
+	defer func() {
+		if r := recover(); r != "action panicked" {
+			t.Errorf("expected the action's panic, got %v", r)
+		}
+
+		if !reflect.DeepEqual(tr, hookTrace{"finally"}) {
+			t.Errorf("finally should run on panic, got %v", tr)
+		}
+	}()
+
+	_ = cmd.RunError([]string{})
+
+	t.Error("the panic should propagate")
+}
@@ -1,16 +1,85 @@
 package interfaces
 
-import "fmt"
+import (
+	"fmt"
+	"strings"
+)
+
+// ErrorKind classifies the errors the parser can return.
+type ErrorKind int
+
+const (
+	// KindOther is used for errors that do not fit in any other category,
+	// for example errors created with ParseErrorf.
+	KindOther ErrorKind = iota
+	// KindSyntax is used for flags that are not correctly formatted.
+	KindSyntax
+	// KindUnknownFlag is used when a flag is not defined for the command.
+	KindUnknownFlag
+	// KindMissingValue is used when a flag needs a value but doesn't get one.
+	KindMissingValue
+	// KindTooFewArgs is used when there are too few positional arguments.
+	KindTooFewArgs
+	// KindTooManyArgs is used when there are too many positional arguments.
+	KindTooManyArgs
+	// KindConversion is used when a value cannot be set from a string.
+	KindConversion
+	// KindPrepare is used when a value cannot be prepared after parsing.
+	KindPrepare
+	// KindValidation is used when the arguments, as a whole, are invalid.
+	KindValidation
+	// KindInvalidCommand is used when a subcommand doesn't exist.
+	KindInvalidCommand
+)
+
+// String returns a string representation of an error kind
+func (k ErrorKind) String() string {
+	switch k {
+	case KindSyntax:
+		return "syntax"
+	case KindUnknownFlag:
+		return "unknown flag"
+	case KindMissingValue:
+		return "missing value"
+	case KindTooFewArgs:
+		return "too few arguments"
+	case KindTooManyArgs:
+		return "too many arguments"
+	case KindConversion:
+		return "conversion"
+	case KindPrepare:
+		return "prepare"
+	case KindValidation:
+		return "validation"
+	case KindInvalidCommand:
+		return "invalid command"
+	default:
+		return "other"
+	}
+}
 
 // ParseError is the type that the parser will return on errors.
-// It implements the error interface.
+// It implements the error interface. Use errors.As to get a ParseError
+// from an error, and errors.Is or errors.As to examine its cause.
 type ParseError struct {
-	Message string
+	Message string    // The error message
+	Kind    ErrorKind // The kind of error
+	Name    string    // Name of the flag or positional argument, if any
+	Index   int       // Index of the offending token in the command's arguments, or -1
+	Token   string    // The offending token, if any
+	Path    []string  // Names of the commands leading to the one that failed
+	Err     error     // The underlying cause, if any
 }
 
 // ParseErrorf creates a ParseError from a format string and arguments.
 func ParseErrorf(format string, args ...interface{}) *ParseError {
-	return &ParseError{fmt.Sprintf(format, args...)}
+	return NewParseError(KindOther, nil, format, args...)
+}
+
+// NewParseError creates a ParseError of a given kind, wrapping cause
+// (which can be nil), with a message from a format string and arguments.
+func NewParseError(kind ErrorKind, cause error, format string, args ...interface{}) *ParseError {
+	return &ParseError{Message: fmt.Sprintf(format, args...), Kind: kind, Index: -1, Err: cause}
 }
 
 // Error returns a string from a ParseError, implementing the error
@@ -19,15 +88,21 @@ func (err *ParseError) Error() string {
 	return err.Message
 }
 
+// Unwrap returns the underlying cause of the error, if any.
+func (err *ParseError) Unwrap() error {
+	return err.Err
+}
+
 // SpecError is the error type returned if there are problems with a
 // specification
 type SpecError struct {
-	Message string
+	Message string // The error message
+	Err     error  // The underlying cause, if any
 }
 
 // SpecErrorf creates a SpecError from a format string and arguments
 func SpecErrorf(format string, args ...interface{}) *SpecError {
-	return &SpecError{fmt.Sprintf(format, args...)}
+	return &SpecError{Message: fmt.Sprintf(format, args...)}
 }
 
 // Error returns a string representation of a SpecError, implementing
@@ -35,3 +110,29 @@ func SpecErrorf(format string, args ...interface{}) *SpecError {
 func (err *SpecError) Error() string {
 	return err.Message
 }
+
+// Unwrap returns the underlying cause of the error, if any.
+func (err *SpecError) Unwrap() error {
+	return err.Err
+}
+
+// ParseErrors holds all the errors found when parsing a command line,
+// when a command is set to collect errors rather than stop at the first.
+// It implements the error interface.
+type ParseErrors []error
+
+// Error returns the messages of all the errors, one per line, implementing
+// the error interface.
+func (errs ParseErrors) Error() string {
+	msgs := make([]string, len(errs))
+	for i, err := range errs {
+		msgs[i] = err.Error()
+	}
+
+	return strings.Join(msgs, "\n")
+}
+
+// Unwrap returns the collected errors.
+func (errs ParseErrors) Unwrap() []error {
+	return errs
+}
@@ -18,6 +18,25 @@ type FlagValue interface {
 	Set(string) error // Should set the value from a string
 }
 
+// ValueFormatter can be implemented by values whose String method doesn't
+// return a string that Set can parse back into the value, for example because
+// String is meant for usage information. FormatValue should return such a string.
+type ValueFormatter interface {
+	FormatValue() string // Should return a string that Set parses back into the value
+}
+
+// VariadicValueFormatter can be implemented by variadic values to format them
+// as a command line.
+type VariadicValueFormatter interface {
+	FormatValues() []string // Should return strings that Set parses back into the value
+}
+
+// Completer can be implemented by values to provide candidates for shell
+// completion, for example values that depend on the state of the system.
+type Completer interface {
+	Complete(prefix string) []string // Should return the possible values that start with prefix
+}
+
 // NoValueFlag is used to indicate that a flag doesn't take any values, and
 // that it is an error to provide one. Their Set() method will be called with
 // the empty string instead.
@@ -54,3 +73,24 @@ type Validator interface {
 type Prepare interface {
 	PrepareValue() error // Called after parsing and before we run a command
 }
+
+// Opener can be implemented by values that open resources, in Set or PrepareValue,
+// but can also hold resources they were given, for example as a default. A command
+// only closes a value that implements io.Closer and Opener if Opened returns true.
+type Opener interface {
+	Opened() bool // Should report whether the value opened the resource it holds
+}
+
+// ArgsValidator can be implemented by the struct returned from a command's Init
+// function to check combinations of values. It is called after flags and
+// positional arguments are parsed and prepared, but before the command's
+// action is invoked.
+type ArgsValidator interface {
+	ValidateArgs() error // Should return nil if the arguments are consistent, or an error otherwise
+}
+
+// ExitCoder can be implemented by errors returned from a command's action
+// to choose the exit status the program terminates with.
+type ExitCoder interface {
+	ExitCode() int // The exit status for the error
+}
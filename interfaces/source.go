@@ -0,0 +1,40 @@
+package interfaces
+
+// Source identifies where a flag or positional argument got its value from.
+type Source int
+
+const (
+	// SourceDefault means that the value was not set, so it still holds
+	// the default from the command's Init function.
+	SourceDefault Source = iota
+	// SourceCommandLine means that the value was set from the command line.
+	SourceCommandLine
+	// SourceEnvironment means that the value was set from an environment variable.
+	SourceEnvironment
+	// SourceConfig means that the value was set from a configuration file.
+	SourceConfig
+)
+
+// String returns a string representation of a source
+func (s Source) String() string {
+	switch s {
+	case SourceDefault:
+		return "default"
+	case SourceCommandLine:
+		return "command line"
+	case SourceEnvironment:
+		return "environment"
+	case SourceConfig:
+		return "config"
+	default:
+		return "unknown"
+	}
+}
+
+// Provenance describes where a value came from. Raw is the string the
+// value was set from, or the string representation of the default if
+// the value was not set.
+type Provenance struct {
+	Source Source // Where the value came from
+	Raw    string // The raw string the value was set from
+}
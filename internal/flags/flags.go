@@ -31,6 +31,16 @@ type Flag struct {
 	Desc     string               // Desc is a short description of the parameter
 	Value    interfaces.FlagValue // Encapsulated value
 	DefValue string               // Default value (as string)
+
+	MetaVar     string // Placeholder for the value in usage, if not the value's own description
+	DefDescr    string // Description of the default in usage, if not DefValue
+	HideDefault bool   // Don't show the default in usage
+	Hidden      bool   // Don't show the flag in usage
+	Group       string // Heading to show the flag under in usage, if not the default
+	Env         string // Environment variable the flag gets its value from, if the command line doesn't set it
+
+	Provenance interfaces.Provenance // Where the current value came from
+	Complete   func(string) []string // Completion candidates for a prefix of the value, if not nil
 }
 
 // FlagSet wraps a set of command line flags.
@@ -39,7 +49,8 @@ type FlagSet struct {
 	longMap   map[string]*Flag
 	shortMap  map[string]*Flag
 
-	args []string // arguments after flags
+	args   []string // arguments after flags
+	parsed []string // the arguments we are parsing
 }
 
 // Lookup gets a flag by name
@@ -54,6 +65,9 @@ func (f *FlagSet) Lookup(name string) *Flag {
 // Args returns the remaining arguments after flags are parsed.
 func (f *FlagSet) Args() []string { return f.args }
 
+// index returns the index of the next argument to parse.
+func (f *FlagSet) index() int { return len(f.parsed) - len(f.args) }
+
 // NewFlagSet creates a new flag set.
 func NewFlagSet() *FlagSet {
 	return &FlagSet{
@@ -84,6 +98,7 @@ func (f *FlagSet) Var(value interfaces.FlagValue, long, short, descr string) err
 		Desc:     flagDescription(value, descr),
 		Value:    value,
 		DefValue: value.String()}
+	flag.resetProvenance()
 
 	if long != "" {
 		f.longMap[long] = flag
@@ -117,37 +132,140 @@ func (f *FlagSet) PrintDefaults(w io.Writer) {
 	fmt.Fprintf(w, "Flags:\n")
 
 	for _, flag := range f.flagsList {
-		defVal := flag.DefValue
-		if defVal != "" {
-			defVal = " (default " + defVal + ")"
+		if !flag.Hidden {
+			printFlag(w, flag)
 		}
+	}
+}
 
-		shortFlag, longFlag := "", ""
+func printFlag(w io.Writer, flag *Flag) {
+	value, def := flag.ValueUsage(), flag.DefaultUsage()
+	if value != "" {
+		value = " " + value
+	}
 
-		if flag.Short != "" {
-			shortFlag = "-" + flag.Short
-		}
+	if def != "" {
+		def = " (default " + def + ")"
+	}
 
-		if flag.Long != "" {
-			if shortFlag != "" {
-				shortFlag += ","
-			}
+	fmt.Fprintf(w, "  %s%s\n\t%s%s\n", flag.Names(), value, flag.Desc, def)
+}
 
-			longFlag = "--" + flag.Long
-		}
+// Group is a list of flags that are shown under the same heading in usage.
+type Group struct {
+	Name  string  // The heading, or "" for flags without a group
+	Flags []*Flag // The flags in the group, in the order they were added
+}
 
-		value := flagValueDescription(flag.Value, "value")
+// Groups returns the flags that are not hidden, divided into groups. The flags
+// without a group come first, followed by the groups in the order their first
+// flags were added.
+func (f *FlagSet) Groups() []Group {
+	groups := []Group{{Name: ""}}
+	index := map[string]int{"": 0}
 
-		if flag.noValues() {
-			value = ""
-		} else if def, ok := flag.hasDefault(); ok {
-			value = " [" + value + "] (no value = " + def + ")"
-		} else {
-			value = " " + value
+	for _, flag := range f.flagsList {
+		if flag.Hidden {
+			continue
 		}
 
-		fmt.Fprintf(w, "  %s%s%s\n\t%s%s\n", shortFlag, longFlag, value, flag.Desc, defVal)
+		i, ok := index[flag.Group]
+		if !ok {
+			i = len(groups)
+			index[flag.Group] = i
+			groups = append(groups, Group{Name: flag.Group})
+		}
+
+		groups[i].Flags = append(groups[i].Flags, flag)
+	}
+
+	if len(groups[0].Flags) == 0 {
+		groups = groups[1:]
 	}
+
+	return groups
+}
+
+// Name returns the flag's long name, or its short name if it doesn't have a
+// long one.
+func (f *Flag) Name() string {
+	if f.Long != "" {
+		return f.Long
+	}
+
+	return f.Short
+}
+
+// Spellings returns the flag's names as they are written on the command line,
+// the long name first, e.g. ["--name", "-n"].
+func (f *Flag) Spellings() []string {
+	names := []string{}
+
+	if f.Long != "" {
+		names = append(names, "--"+f.Long)
+	}
+
+	if f.Short != "" {
+		names = append(names, "-"+f.Short)
+	}
+
+	return names
+}
+
+// Names returns the flag's names as they are shown in usage, e.g. "-n,--name".
+func (f *Flag) Names() string {
+	names := f.Spellings()
+	for i, j := 0, len(names)-1; i < j; i, j = i+1, j-1 {
+		names[i], names[j] = names[j], names[i]
+	}
+
+	return strings.Join(names, ",")
+}
+
+// ValueUsage returns the description of the flag's value as it is shown in usage,
+// or the empty string if the flag doesn't take a value.
+func (f *Flag) ValueUsage() string {
+	value := f.ValueDescription()
+	if f.MetaVar != "" {
+		value = f.MetaVar
+	}
+
+	if f.noValues() {
+		return ""
+	}
+
+	if def, ok := f.hasDefault(); ok {
+		return "[" + value + "] (no value = " + def + ")"
+	}
+
+	return value
+}
+
+// ValueDescription returns the description of the type of the flag's value,
+// e.g. "integer", or "value" if the value doesn't describe itself.
+func (f *Flag) ValueDescription() string {
+	return flagValueDescription(f.Value, "value")
+}
+
+// DefaultUsage returns the flag's default as it is shown in usage, or the empty
+// string if the default isn't shown.
+func (f *Flag) DefaultUsage() string {
+	if f.HideDefault {
+		return ""
+	}
+
+	if f.DefDescr != "" {
+		return f.DefDescr
+	}
+
+	return f.DefValue
+}
+
+// TakesValue reports whether the flag takes its value from the following
+// argument when it is used without a "=value".
+func (f *Flag) TakesValue() bool {
+	_, hasDefault := f.hasDefault()
+	return !f.noValues() && !hasDefault
 }
 
 func (f *Flag) noValues() bool {
@@ -166,22 +284,55 @@ func (f *Flag) hasDefault() (string, bool) {
 	return "", false
 }
 
-func wrapShortParseError(name string, err error) error {
+func (f *Flag) resetProvenance() {
+	f.Provenance = interfaces.Provenance{Source: interfaces.SourceDefault, Raw: f.DefValue}
+}
+
+// SetFrom sets the flag's value from a string and records that the value
+// came from src.
+func (f *Flag) SetFrom(value string, src interfaces.Source) error {
+	if err := f.Value.Set(value); err != nil {
+		return err
+	}
+
+	f.Provenance = interfaces.Provenance{Source: src, Raw: value}
+
+	return nil
+}
+
+// set sets the flag's value from the command line.
+func (f *Flag) set(value string) error {
+	return f.SetFrom(value, interfaces.SourceCommandLine)
+}
+
+// errorf creates a parse error about the flag name, caused by the token at index
+// idx in the arguments we are parsing.
+func (f *FlagSet) errorf(kind interfaces.ErrorKind, name string, idx int, cause error,
+	format string, args ...interface{}) error {
+	err := interfaces.NewParseError(kind, cause, format, args...)
+	err.Name, err.Index, err.Token = name, idx, f.parsed[idx]
+
+	return err
+}
+
+func (f *FlagSet) wrapShortParseError(name string, idx int, err error) error {
 	if err != nil {
-		return interfaces.ParseErrorf("parsing flag -%s: %s", name, err)
+		return f.errorf(interfaces.KindConversion, name, idx, err, "parsing flag -%s: %s", name, err)
 	}
 
 	return nil
 }
 
 func (f *FlagSet) parseShort() error {
+	idx := f.index()
 	flags := f.args[0][1:]
 	f.args = f.args[1:]
 
 	// Just to avoid some common error...
 	for i := 0; i < len(flags); i++ {
 		if flags[i] == '=' {
-			return interfaces.ParseErrorf("--flag=value syntax for flags only allowed for long options: -%s", flags)
+			return f.errorf(interfaces.KindSyntax, "", idx, nil,
+				"--flag=value syntax for flags only allowed for long options: -%s", flags)
 		}
 	}
 
@@ -191,18 +342,18 @@ func (f *FlagSet) parseShort() error {
 		flag, valid := f.shortMap[x]
 
 		if !valid {
-			return interfaces.ParseErrorf("flag provided but not defined: -%s", x)
+			return f.errorf(interfaces.KindUnknownFlag, x, idx, nil, "flag provided but not defined: -%s", x)
 		} else if flag.noValues() {
-			if err := flag.Value.Set(""); err != nil {
-				return interfaces.ParseErrorf("evaluating flag -%s: %s", x, err)
+			if err := flag.set(""); err != nil {
+				return f.errorf(interfaces.KindConversion, x, idx, err, "evaluating flag -%s: %s", x, err)
 			}
 		} else if def, ok := flag.hasDefault(); ok {
-			if err := flag.Value.Set(def); err != nil {
-				return interfaces.ParseErrorf("evaluating flag -%s: %s", x, err)
+			if err := flag.set(def); err != nil {
+				return f.errorf(interfaces.KindConversion, x, idx, err, "evaluating flag -%s: %s", x, err)
 			}
 		} else {
 			// only the last flag in flags get a value, and this isn't it
-			return interfaces.ParseErrorf("flag -%s needs an argument", x)
+			return f.errorf(interfaces.KindMissingValue, x, idx, nil, "flag -%s needs an argument", x)
 		}
 	}
 
@@ -210,41 +361,43 @@ func (f *FlagSet) parseShort() error {
 	flag, valid := f.shortMap[x]
 
 	if !valid {
-		return interfaces.ParseErrorf("flag provided but not defined: -%s", x)
+		return f.errorf(interfaces.KindUnknownFlag, x, idx, nil, "flag provided but not defined: -%s", x)
 	}
 
 	if flag.noValues() {
-		return wrapShortParseError(x, flag.Value.Set(""))
+		return f.wrapShortParseError(x, idx, flag.set(""))
 	}
 
 	if def, ok := flag.hasDefault(); ok {
-		return wrapShortParseError(x, flag.Value.Set(def))
+		return f.wrapShortParseError(x, idx, flag.set(def))
 	}
 
 	if len(f.args) == 0 || f.args[0][0] == '-' {
-		return interfaces.ParseErrorf("flag -%s needs an argument", x)
+		return f.errorf(interfaces.KindMissingValue, x, idx, nil, "flag -%s needs an argument", x)
 	}
 
 	// get the next argument as the value for the flag
+	idx = f.index()
 	value := f.args[0]
 	f.args = f.args[1:]
 
-	return wrapShortParseError(x, flag.Value.Set(value))
+	return f.wrapShortParseError(x, idx, flag.set(value))
 }
 
-func wrapLongParseError(name string, err error) error {
+func (f *FlagSet) wrapLongParseError(name string, idx int, err error) error {
 	if err != nil {
-		return interfaces.ParseErrorf("parsing flag --%s: %s", name, err)
+		return f.errorf(interfaces.KindConversion, name, idx, err, "parsing flag --%s: %s", name, err)
 	}
 
 	return nil
 }
 
 func (f *FlagSet) parseLong() error {
+	idx := f.index()
 	name := f.args[0][2:]
 
 	if name == "" || name[0] == '-' || name[0] == '=' {
-		return interfaces.ParseErrorf("bad flag syntax: %s", f.args[0])
+		return f.errorf(interfaces.KindSyntax, "", idx, nil, "bad flag syntax: %s", f.args[0])
 	}
 
 	// it's a flag. does it have an argument?
@@ -264,21 +417,21 @@ func (f *FlagSet) parseLong() error {
 
 	flag, valid := f.longMap[name]
 	if !valid {
-		return interfaces.ParseErrorf("flag provided but not defined: --%s", name)
+		return f.errorf(interfaces.KindUnknownFlag, name, idx, nil, "flag provided but not defined: --%s", name)
 	}
 
 	if hasValue {
 		if flag.noValues() {
-			return interfaces.ParseErrorf("flag --%s cannot take values", name)
+			return f.errorf(interfaces.KindSyntax, name, idx, nil, "flag --%s cannot take values", name)
 		}
 
-		return wrapLongParseError(name, flag.Value.Set(value))
+		return f.wrapLongParseError(name, idx, flag.set(value))
 	}
 
 	if flag.noValues() {
 		// we don't take values, so we can stop with this flag. Invoke it by
 		// calling Set() with the empty string
-		return wrapLongParseError(name, flag.Value.Set(""))
+		return f.wrapLongParseError(name, idx, flag.set(""))
 	}
 
 	if def, ok := flag.hasDefault(); ok {
@@ -287,17 +440,18 @@ func (f *FlagSet) parseLong() error {
 		// flag argument or a positional argument. So if we have one of
 		// those, then we invoke it here, and do not look at the following
 		// arg.
-		return wrapLongParseError(name, flag.Value.Set(def))
+		return f.wrapLongParseError(name, idx, flag.set(def))
 	}
 
 	if len(f.args) == 0 || f.args[0][0] == '-' {
-		return interfaces.ParseErrorf("flag --%s needs an argument", name)
+		return f.errorf(interfaces.KindMissingValue, name, idx, nil, "flag --%s needs an argument", name)
 	}
 
 	// get the next argument as the value for the flag
+	idx = f.index()
 	value, f.args = f.args[0], f.args[1:]
 
-	return wrapLongParseError(name, flag.Value.Set(value))
+	return f.wrapLongParseError(name, idx, flag.set(value))
 }
 
 func (f *FlagSet) parseOne() (more bool, err error) {
@@ -334,17 +488,52 @@ func (f *FlagSet) parseOne() (more bool, err error) {
 // Parse parses the flags in the args, leaving the remaining arguments
 // in f.Args().
 func (f *FlagSet) Parse(args []string) error {
-	f.args = args
+	return f.parse(args, false)
+}
+
+// ParseAll parses the flags in the args, like Parse, but it doesn't stop
+// at the first error. Instead, it returns all the errors it finds as
+// interfaces.ParseErrors, or nil if there are no errors.
+func (f *FlagSet) ParseAll(args []string) error {
+	return f.parse(args, true)
+}
+
+func (f *FlagSet) parse(args []string, all bool) error {
+	f.args, f.parsed = args, args
+
+	for _, flag := range f.flagsList {
+		flag.resetProvenance()
+	}
+
+	var errs interfaces.ParseErrors
 
 	for {
+		n := len(f.args)
 		more, err := f.parseOne()
 
 		if err != nil {
-			return err
+			if !all {
+				return err
+			}
+
+			errs = append(errs, err)
+
+			// make sure that we always make progress
+			if len(f.args) == n {
+				f.args = f.args[1:]
+			}
+
+			continue
 		}
 
 		if !more {
-			return nil
+			break
 		}
 	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+
+	return nil
 }
@@ -494,3 +494,191 @@ func TestDescriptionHooks(t *testing.T) {
 		t.Errorf("unexpected: %s", expected)
 	}
 }
+
+func TestProvenance(t *testing.T) {
+	var (
+		i vals.IntValue = 13
+		b vals.BoolValue
+	)
+
+	f := flags.NewFlagSet()
+	_ = f.Var(&i, "int", "i", "")
+	_ = f.Var(&b, "bool", "b", "")
+
+	if prov := f.Lookup("int").Provenance; prov.Source != interfaces.SourceDefault || prov.Raw != "13" {
+		t.Errorf("unexpected provenance before parsing: %v", prov)
+	}
+
+	if err := f.Parse([]string{"-b", "--int", "42"}); err != nil {
+		t.Fatalf("unexpected parse error: %s", err)
+	}
+
+	if prov := f.Lookup("i").Provenance; prov.Source != interfaces.SourceCommandLine || prov.Raw != "42" {
+		t.Errorf("unexpected provenance for int: %v", prov)
+	}
+
+	if prov := f.Lookup("b").Provenance; prov.Source != interfaces.SourceCommandLine || prov.Raw != "true" {
+		t.Errorf("unexpected provenance for bool: %v", prov)
+	}
+
+	// parsing again resets the provenance
+	if err := f.Parse([]string{"-b"}); err != nil {
+		t.Fatalf("unexpected parse error: %s", err)
+	}
+
+	if prov := f.Lookup("int").Provenance; prov.Source != interfaces.SourceDefault || prov.Raw != "13" {
+		t.Errorf("unexpected provenance after reparsing: %v", prov)
+	}
+}
+
+func TestUsageOverrides(t *testing.T) {
+	var (
+		port vals.IntValue    = 8080
+		dir  vals.StringValue = "/home/me/.cache"
+		cpus vals.IntValue    = 16
+		dbg  vals.BoolValue
+	)
+
+	f := flags.NewFlagSet()
+	_ = f.Var(&port, "port", "", "port to listen on")
+	_ = f.Var(&dir, "cache", "", "cache directory")
+	_ = f.Var(&cpus, "cpus", "", "number of workers")
+	_ = f.Var(&dbg, "debug", "", "debug output")
+
+	f.Lookup("port").MetaVar = "PORT"
+	f.Lookup("cache").DefDescr = "$HOME/.cache"
+	f.Lookup("cpus").HideDefault = true
+	f.Lookup("debug").Hidden = true
+
+	builder := new(strings.Builder)
+	f.PrintDefaults(builder)
+
+	expected := `Flags:
+  --port PORT
+	port to listen on (default 8080)
+  --cache string
+	cache directory (default $HOME/.cache)
+  --cpus integer
+	number of workers
+`
+	if msg := builder.String(); msg != expected {
+		t.Errorf("Unexpected usage:\n%s", msg)
+	}
+}
+
+func TestParseAll(t *testing.T) {
+	var (
+		i vals.IntValue
+		b vals.BoolValue
+		s vals.StringValue
+	)
+
+	f := flags.NewFlagSet()
+	_ = f.Var(&i, "int", "i", "")
+	_ = f.Var(&b, "bool", "b", "")
+	_ = f.Var(&s, "str", "s", "")
+
+	err := f.ParseAll([]string{"-i", "foo", "--foo", "---", "--bool=maybe", "-s", "bar", "-x", "--str", "--", "rest"})
+	if err == nil {
+		t.Fatal("expected errors")
+	}
+
+	errs, ok := err.(interfaces.ParseErrors)
+	if !ok {
+		t.Fatalf("expected ParseErrors but got %T", err)
+	}
+
+	expected := []string{
+		`parsing flag -i: argument "foo" cannot be parsed as int`,
+		"flag provided but not defined: --foo",
+		"bad flag syntax: ---",
+		`parsing flag --bool: argument "maybe" cannot be parsed as bool`,
+		"flag provided but not defined: -x",
+		"flag --str needs an argument",
+	}
+
+	if len(errs) != len(expected) {
+		t.Fatalf("expected %d errors but got %d: %s", len(expected), len(errs), err)
+	}
+
+	for i, e := range errs {
+		if e.Error() != expected[i] {
+			t.Errorf("unexpected error %d: %s", i, e)
+		}
+	}
+
+	if s != "bar" {
+		t.Error("flags after errors should still be parsed")
+	}
+
+	if !reflect.DeepEqual(f.Args(), []string{"rest"}) {
+		t.Errorf("unexpected remaining args: %v", f.Args())
+	}
+
+	if err := f.ParseAll([]string{"-i", "42"}); err != nil {
+		t.Errorf("unexpected error: %s", err)
+	}
+}
+
+func TestGroups(t *testing.T) {
+	var a, b, c, d vals.IntValue
+
+	f := flags.NewFlagSet()
+	_ = f.Var(&a, "a", "", "")
+	_ = f.Var(&b, "b", "", "")
+	_ = f.Var(&c, "c", "", "")
+	_ = f.Var(&d, "d", "", "")
+
+	f.Lookup("a").Group = "Net"
+	f.Lookup("b").Group = "Net"
+	f.Lookup("c").Hidden = true
+	f.Lookup("c").Group = "Secret"
+
+	groups := f.Groups()
+	if len(groups) != 2 || groups[0].Name != "" || groups[1].Name != "Net" {
+		t.Fatalf("unexpected groups: %+v", groups)
+	}
+
+	if len(groups[0].Flags) != 1 || groups[0].Flags[0].Long != "d" {
+		t.Errorf("unexpected flags without a group: %+v", groups[0].Flags)
+	}
+
+	if len(groups[1].Flags) != 2 || groups[1].Flags[0].Long != "a" || groups[1].Flags[1].Long != "b" {
+		t.Errorf("unexpected flags in group: %+v", groups[1].Flags)
+	}
+}
+
+func TestFlagNames(t *testing.T) {
+	var a, b, c vals.IntValue
+
+	f := flags.NewFlagSet()
+	_ = f.Var(&a, "aa", "a", "")
+	_ = f.Var(&b, "bb", "", "")
+	_ = f.Var(&c, "", "c", "")
+
+	tests := []struct {
+		name      string
+		spellings []string
+		names     string
+	}{
+		{"aa", []string{"--aa", "-a"}, "-a,--aa"},
+		{"bb", []string{"--bb"}, "--bb"},
+		{"c", []string{"-c"}, "-c"},
+	}
+
+	for i, tt := range tests {
+		flag := f.Flag(i)
+
+		if flag.Name() != tt.name {
+			t.Errorf("unexpected name: %s", flag.Name())
+		}
+
+		if !reflect.DeepEqual(flag.Spellings(), tt.spellings) {
+			t.Errorf("unexpected spellings: %v", flag.Spellings())
+		}
+
+		if flag.Names() != tt.names {
+			t.Errorf("unexpected names: %s", flag.Names())
+		}
+	}
+}
@@ -24,6 +24,10 @@ type Param struct {
 	Desc string
 	// Encapsulated value
 	Value interfaces.PosValue
+	// Provenance is where the current value came from
+	Provenance interfaces.Provenance
+	// Complete returns completion candidates for a prefix of the value, if not nil
+	Complete func(prefix string) []string
 }
 
 // VariadicParam holds information about a variadic argument.
@@ -37,6 +41,22 @@ type VariadicParam struct {
 	Min int
 	// Encapsulated value
 	Value interfaces.VariadicValue
+	// Provenance is where the current value came from
+	Provenance interfaces.Provenance
+	// Complete returns completion candidates for a prefix of the values, if not nil
+	Complete func(prefix string) []string
+}
+
+// defaultProvenance gives a parameter that hasn't been set from the command line
+// the default source. If the value can be formatted as a string, we use that as
+// the raw value.
+func defaultProvenance(val interface{}) interfaces.Provenance {
+	prov := interfaces.Provenance{Source: interfaces.SourceDefault}
+	if fv, ok := val.(interfaces.FlagValue); ok {
+		prov.Raw = fv.String()
+	}
+
+	return prov
 }
 
 // ParamSet contains a list of specified parameters for a
@@ -103,6 +123,50 @@ func (p *ParamSet) ShortUsage() string {
 	return namesUsage
 }
 
+func (p *ParamSet) resetProvenance() {
+	for _, par := range p.params {
+		par.Provenance = defaultProvenance(par.Value)
+	}
+
+	if p.last != nil {
+		p.last.Provenance = defaultProvenance(p.last.Value)
+	}
+}
+
+// errorf creates a parse error about the parameter name, caused by the
+// argument at index idx (or -1 if there isn't a specific argument).
+func errorf(kind interfaces.ErrorKind, name string, idx int, token string, cause error,
+	format string, args ...interface{}) error {
+	err := interfaces.NewParseError(kind, cause, format, args...)
+	err.Name, err.Index, err.Token = name, idx, token
+
+	return err
+}
+
+func (par *Param) set(idx int, arg string) error {
+	if err := par.Value.Set(arg); err != nil {
+		return errorf(interfaces.KindConversion, par.Name, idx, arg, err,
+			"error parsing parameter %s='%s'", par.Name, arg)
+	}
+
+	par.Provenance = interfaces.Provenance{Source: interfaces.SourceCommandLine, Raw: arg}
+
+	return nil
+}
+
+func (par *VariadicParam) set(idx int, args []string) error {
+	if err := par.Value.Set(args); err != nil {
+		return errorf(interfaces.KindConversion, par.Name, idx, strings.Join(args, " "), err,
+			"error parsing parameters %s='%v'", par.Name, args)
+	}
+
+	if len(args) > 0 { // without arguments, the variadic keeps its default
+		par.Provenance = interfaces.Provenance{Source: interfaces.SourceCommandLine, Raw: strings.Join(args, " ")}
+	}
+
+	return nil
+}
+
 // Parse parses arguments against parameters.
 //
 // Parameters:
@@ -114,32 +178,77 @@ func (p *ParamSet) ShortUsage() string {
 // it will return an error instead. If all goes well, it will
 // return nil.
 func (p *ParamSet) Parse(args []string) error {
+	p.resetProvenance()
+
 	minParams := len(p.params)
 	if p.last != nil {
 		minParams += p.last.Min
 	}
 
 	if len(args) < minParams {
-		return interfaces.ParseErrorf("too few arguments")
+		return errorf(interfaces.KindTooFewArgs, "", -1, "", nil, "too few arguments")
 	}
 
 	if p.last == nil && len(args) > len(p.params) {
-		return interfaces.ParseErrorf("too many arguments")
+		return errorf(interfaces.KindTooManyArgs, "", len(p.params), args[len(p.params)], nil, "too many arguments")
 	}
 
 	for i, par := range p.params {
-		if err := par.Value.Set(args[i]); err != nil {
-			return interfaces.ParseErrorf("error parsing parameter %s='%s'", par.Name, args[i])
+		if err := par.set(i, args[i]); err != nil {
+			return err
 		}
 	}
 
 	if p.last != nil {
-		rest := args[len(p.params):]
-		if err := p.last.Value.Set(rest); err != nil {
-			return interfaces.ParseErrorf("error parsing parameters %s='%v'", p.last.Name, rest)
+		return p.last.set(len(p.params), args[len(p.params):])
+	}
+
+	return nil
+}
+
+// ParseAll parses arguments against parameters, like Parse, but it doesn't
+// stop at the first error. Instead, it returns all the errors it finds as
+// interfaces.ParseErrors, or nil if there are no errors.
+func (p *ParamSet) ParseAll(args []string) error {
+	p.resetProvenance()
+
+	var errs interfaces.ParseErrors
+
+	for i, par := range p.params {
+		if i >= len(args) {
+			errs = append(errs, errorf(interfaces.KindTooFewArgs, par.Name, -1, "", nil, "missing argument %s", par.Name))
+			continue
+		}
+
+		if err := par.set(i, args[i]); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	var rest []string
+	if len(args) > len(p.params) {
+		rest = args[len(p.params):]
+	}
+
+	switch {
+	case p.last == nil && len(rest) > 0:
+		errs = append(errs, errorf(interfaces.KindTooManyArgs, "", len(p.params), rest[0], nil,
+			"too many arguments: %v", rest))
+
+	case p.last != nil && len(rest) < p.last.Min:
+		errs = append(errs, errorf(interfaces.KindTooFewArgs, p.last.Name, -1, "", nil,
+			"too few arguments for %s: expected at least %d but got %d", p.last.Name, p.last.Min, len(rest)))
+
+	case p.last != nil:
+		if err := p.last.set(len(p.params), rest); err != nil {
+			errs = append(errs, err)
 		}
 	}
 
+	if len(errs) > 0 {
+		return errs
+	}
+
 	return nil
 }
 
@@ -150,7 +259,12 @@ func (p *ParamSet) Parse(args []string) error {
 //   - name: Name of the argument, used when printing usage.
 //   - desc: Description of the argument. Used when printing usage.
 func (p *ParamSet) Var(val interfaces.PosValue, name, desc string) {
-	p.params = append(p.params, &Param{name, paramDescription(val, desc), val})
+	p.params = append(p.params, &Param{
+		Name:       name,
+		Desc:       paramDescription(val, desc),
+		Value:      val,
+		Provenance: defaultProvenance(val),
+	})
 }
 
 // VariadicVar install a variadic argument
@@ -163,5 +277,11 @@ func (p *ParamSet) Var(val interfaces.PosValue, name, desc string) {
 //   - min: The minimum number of arguments that the command line must
 //     have for this parameter.
 func (p *ParamSet) VariadicVar(val interfaces.VariadicValue, name, desc string, min int) {
-	p.last = &VariadicParam{Name: name, Desc: paramDescription(val, desc), Min: min, Value: val}
+	p.last = &VariadicParam{
+		Name:       name,
+		Desc:       paramDescription(val, desc),
+		Min:        min,
+		Value:      val,
+		Provenance: defaultProvenance(val),
+	}
 }
@@ -7,6 +7,7 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/mailund/cli/interfaces"
 	"github.com/mailund/cli/internal/params"
 	"github.com/mailund/cli/internal/vals"
 )
@@ -515,3 +516,96 @@ func TestDescriptionHooks(t *testing.T) {
 		t.Errorf("unexpected: %s", expected)
 	}
 }
+
+func TestProvenance(t *testing.T) {
+	var (
+		x  = "foo"
+		xs []string
+	)
+
+	p := params.NewParamSet()
+	p.Var((*vals.StringValue)(&x), "x", "")
+	p.VariadicVar((*vals.VariadicStringValue)(&xs), "xs", "", 0)
+
+	if prov := p.Param(0).Provenance; prov.Source != interfaces.SourceDefault || prov.Raw != "foo" {
+		t.Errorf("unexpected provenance before parsing: %v", prov)
+	}
+
+	if err := p.Parse([]string{"bar", "baz", "qux"}); err != nil {
+		t.Fatalf("unexpected parse error: %s", err)
+	}
+
+	if prov := p.Param(0).Provenance; prov.Source != interfaces.SourceCommandLine || prov.Raw != "bar" {
+		t.Errorf("unexpected provenance for x: %v", prov)
+	}
+
+	if prov := p.Variadic().Provenance; prov.Source != interfaces.SourceCommandLine || prov.Raw != "baz qux" {
+		t.Errorf("unexpected provenance for xs: %v", prov)
+	}
+	if err := p.Parse([]string{"bar"}); err != nil {
+		t.Fatalf("unexpected parse error: %s", err)
+	}
+
+	if prov := p.Variadic().Provenance; prov.Source != interfaces.SourceDefault {
+		t.Errorf("xs should not be set without arguments: %v", prov)
+	}
+}
+
+func TestParseAll(t *testing.T) {
+	var (
+		x, y int
+		zs   []int
+	)
+
+	p := params.NewParamSet()
+	p.Var((*vals.IntValue)(&x), "x", "")
+	p.Var((*vals.IntValue)(&y), "y", "")
+
+	checkErrors := func(err error, expected []string) {
+		t.Helper()
+
+		errs, ok := err.(interfaces.ParseErrors)
+		if !ok {
+			t.Fatalf("expected ParseErrors but got %T (%v)", err, err)
+		}
+
+		if len(errs) != len(expected) {
+			t.Fatalf("expected %d errors but got %d: %s", len(expected), len(errs), err)
+		}
+
+		for i, e := range errs {
+			if e.Error() != expected[i] {
+				t.Errorf("unexpected error %d: %s", i, e)
+			}
+		}
+	}
+
+	checkErrors(p.ParseAll([]string{"foo"}), []string{
+		"error parsing parameter x='foo'",
+		"missing argument y",
+	})
+
+	checkErrors(p.ParseAll([]string{"1", "bar", "baz"}), []string{
+		"error parsing parameter y='bar'",
+		"too many arguments: [baz]",
+	})
+
+	p.VariadicVar((*vals.VariadicIntValue)(&zs), "zs", "", 2)
+
+	checkErrors(p.ParseAll([]string{"1", "2", "3"}), []string{
+		"too few arguments for zs: expected at least 2 but got 1",
+	})
+
+	checkErrors(p.ParseAll([]string{"a", "2", "3", "b"}), []string{
+		"error parsing parameter x='a'",
+		"error parsing parameters zs='[3 b]'",
+	})
+
+	if err := p.ParseAll([]string{"1", "2", "3", "4"}); err != nil {
+		t.Errorf("unexpected error: %s", err)
+	}
+
+	if x != 1 || y != 2 || !reflect.DeepEqual(zs, []int{3, 4}) {
+		t.Errorf("unexpected values: %d %d %v", x, y, zs)
+	}
+}
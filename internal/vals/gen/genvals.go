@@ -269,6 +269,15 @@ func (val *{{VariadicTypeName .TypeName}}) FlagValueDescription() string {
 	return "{{.FlagValueDescription}}(s)"
 }
 
+func (vals *{{VariadicTypeName .TypeName}}) FormatValues() []string {
+	xs := make([]string, len(*vals))
+	for i := range *vals {
+		xs[i] = (*{{TypeName .TypeName}})(&(*vals)[i]).String()
+	}
+
+	return xs
+}
+
 
 func {{VariadicTypeName .TypeName}}Constructor(val reflect.Value) interfaces.VariadicValue {
 	return (*{{VariadicTypeName .TypeName}})(val.Interface().(*[]{{.TypeName}}))
@@ -331,6 +340,12 @@ func Test{{VariadicTypeName .TypeName}}(t *testing.T) {
 
 	if !reflect.DeepEqual(x, []{{.TypeName}}{ {{.VarOutput}} }) {
 		t.Error("x holds the wrong value")
+	}
+
+	if f, ok := vv.(interfaces.VariadicValueFormatter); !ok {
+		t.Error("We should be able to format the values")
+	} else if err := vv.Set(f.FormatValues()); err != nil || !reflect.DeepEqual(x, []{{.TypeName}}{ {{.VarOutput}} }) {
+		t.Errorf("Formatted values do not parse back into the values: %v", f.FormatValues())
 	}{{ if (not .CantFail) }}
 
 	if err := vv.Set([]string{ {{.VarFailInput}} }); err == nil {
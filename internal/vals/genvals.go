@@ -43,6 +43,15 @@ func (val *VariadicStringValue) FlagValueDescription() string {
 	return "string(s)"
 }
 
+func (vals *VariadicStringValue) FormatValues() []string {
+	xs := make([]string, len(*vals))
+	for i := range *vals {
+		xs[i] = (*StringValue)(&(*vals)[i]).String()
+	}
+
+	return xs
+}
+
 
 func VariadicStringValueConstructor(val reflect.Value) interfaces.VariadicValue {
 	return (*VariadicStringValue)(val.Interface().(*[]string))
@@ -94,6 +103,15 @@ func (val *VariadicBoolValue) FlagValueDescription() string {
 	return "boolean(s)"
 }
 
+func (vals *VariadicBoolValue) FormatValues() []string {
+	xs := make([]string, len(*vals))
+	for i := range *vals {
+		xs[i] = (*BoolValue)(&(*vals)[i]).String()
+	}
+
+	return xs
+}
+
 
 func VariadicBoolValueConstructor(val reflect.Value) interfaces.VariadicValue {
 	return (*VariadicBoolValue)(val.Interface().(*[]bool))
@@ -145,6 +163,15 @@ func (val *VariadicIntValue) FlagValueDescription() string {
 	return "integer(s)"
 }
 
+func (vals *VariadicIntValue) FormatValues() []string {
+	xs := make([]string, len(*vals))
+	for i := range *vals {
+		xs[i] = (*IntValue)(&(*vals)[i]).String()
+	}
+
+	return xs
+}
+
 
 func VariadicIntValueConstructor(val reflect.Value) interfaces.VariadicValue {
 	return (*VariadicIntValue)(val.Interface().(*[]int))
@@ -196,6 +223,15 @@ func (val *VariadicInt8Value) FlagValueDescription() string {
 	return "integer(s)"
 }
 
+func (vals *VariadicInt8Value) FormatValues() []string {
+	xs := make([]string, len(*vals))
+	for i := range *vals {
+		xs[i] = (*Int8Value)(&(*vals)[i]).String()
+	}
+
+	return xs
+}
+
 
 func VariadicInt8ValueConstructor(val reflect.Value) interfaces.VariadicValue {
 	return (*VariadicInt8Value)(val.Interface().(*[]int8))
@@ -247,6 +283,15 @@ func (val *VariadicInt16Value) FlagValueDescription() string {
 	return "integer(s)"
 }
 
+func (vals *VariadicInt16Value) FormatValues() []string {
+	xs := make([]string, len(*vals))
+	for i := range *vals {
+		xs[i] = (*Int16Value)(&(*vals)[i]).String()
+	}
+
+	return xs
+}
+
 
 func VariadicInt16ValueConstructor(val reflect.Value) interfaces.VariadicValue {
 	return (*VariadicInt16Value)(val.Interface().(*[]int16))
@@ -298,6 +343,15 @@ func (val *VariadicInt32Value) FlagValueDescription() string {
 	return "integer(s)"
 }
 
+func (vals *VariadicInt32Value) FormatValues() []string {
+	xs := make([]string, len(*vals))
+	for i := range *vals {
+		xs[i] = (*Int32Value)(&(*vals)[i]).String()
+	}
+
+	return xs
+}
+
 
 func VariadicInt32ValueConstructor(val reflect.Value) interfaces.VariadicValue {
 	return (*VariadicInt32Value)(val.Interface().(*[]int32))
@@ -349,6 +403,15 @@ func (val *VariadicInt64Value) FlagValueDescription() string {
 	return "integer(s)"
 }
 
+func (vals *VariadicInt64Value) FormatValues() []string {
+	xs := make([]string, len(*vals))
+	for i := range *vals {
+		xs[i] = (*Int64Value)(&(*vals)[i]).String()
+	}
+
+	return xs
+}
+
 
 func VariadicInt64ValueConstructor(val reflect.Value) interfaces.VariadicValue {
 	return (*VariadicInt64Value)(val.Interface().(*[]int64))
@@ -400,6 +463,15 @@ func (val *VariadicUintValue) FlagValueDescription() string {
 	return "unsigned integer(s)"
 }
 
+func (vals *VariadicUintValue) FormatValues() []string {
+	xs := make([]string, len(*vals))
+	for i := range *vals {
+		xs[i] = (*UintValue)(&(*vals)[i]).String()
+	}
+
+	return xs
+}
+
 
 func VariadicUintValueConstructor(val reflect.Value) interfaces.VariadicValue {
 	return (*VariadicUintValue)(val.Interface().(*[]uint))
@@ -451,6 +523,15 @@ func (val *VariadicUint8Value) FlagValueDescription() string {
 	return "unsigned integer(s)"
 }
 
+func (vals *VariadicUint8Value) FormatValues() []string {
+	xs := make([]string, len(*vals))
+	for i := range *vals {
+		xs[i] = (*Uint8Value)(&(*vals)[i]).String()
+	}
+
+	return xs
+}
+
 
 func VariadicUint8ValueConstructor(val reflect.Value) interfaces.VariadicValue {
 	return (*VariadicUint8Value)(val.Interface().(*[]uint8))
@@ -502,6 +583,15 @@ func (val *VariadicUint16Value) FlagValueDescription() string {
 	return "unsigned integer(s)"
 }
 
+func (vals *VariadicUint16Value) FormatValues() []string {
+	xs := make([]string, len(*vals))
+	for i := range *vals {
+		xs[i] = (*Uint16Value)(&(*vals)[i]).String()
+	}
+
+	return xs
+}
+
 
 func VariadicUint16ValueConstructor(val reflect.Value) interfaces.VariadicValue {
 	return (*VariadicUint16Value)(val.Interface().(*[]uint16))
@@ -553,6 +643,15 @@ func (val *VariadicUint32Value) FlagValueDescription() string {
 	return "unsigned integer(s)"
 }
 
+func (vals *VariadicUint32Value) FormatValues() []string {
+	xs := make([]string, len(*vals))
+	for i := range *vals {
+		xs[i] = (*Uint32Value)(&(*vals)[i]).String()
+	}
+
+	return xs
+}
+
 
 func VariadicUint32ValueConstructor(val reflect.Value) interfaces.VariadicValue {
 	return (*VariadicUint32Value)(val.Interface().(*[]uint32))
@@ -604,6 +703,15 @@ func (val *VariadicUint64Value) FlagValueDescription() string {
 	return "unsigned integer(s)"
 }
 
+func (vals *VariadicUint64Value) FormatValues() []string {
+	xs := make([]string, len(*vals))
+	for i := range *vals {
+		xs[i] = (*Uint64Value)(&(*vals)[i]).String()
+	}
+
+	return xs
+}
+
 
 func VariadicUint64ValueConstructor(val reflect.Value) interfaces.VariadicValue {
 	return (*VariadicUint64Value)(val.Interface().(*[]uint64))
@@ -655,6 +763,15 @@ func (val *VariadicFloat32Value) FlagValueDescription() string {
 	return "floating point number(s)"
 }
 
+func (vals *VariadicFloat32Value) FormatValues() []string {
+	xs := make([]string, len(*vals))
+	for i := range *vals {
+		xs[i] = (*Float32Value)(&(*vals)[i]).String()
+	}
+
+	return xs
+}
+
 
 func VariadicFloat32ValueConstructor(val reflect.Value) interfaces.VariadicValue {
 	return (*VariadicFloat32Value)(val.Interface().(*[]float32))
@@ -706,6 +823,15 @@ func (val *VariadicFloat64Value) FlagValueDescription() string {
 	return "floating point number(s)"
 }
 
+func (vals *VariadicFloat64Value) FormatValues() []string {
+	xs := make([]string, len(*vals))
+	for i := range *vals {
+		xs[i] = (*Float64Value)(&(*vals)[i]).String()
+	}
+
+	return xs
+}
+
 
 func VariadicFloat64ValueConstructor(val reflect.Value) interfaces.VariadicValue {
 	return (*VariadicFloat64Value)(val.Interface().(*[]float64))
@@ -757,6 +883,15 @@ func (val *VariadicComplex64Value) FlagValueDescription() string {
 	return "complex number(s)"
 }
 
+func (vals *VariadicComplex64Value) FormatValues() []string {
+	xs := make([]string, len(*vals))
+	for i := range *vals {
+		xs[i] = (*Complex64Value)(&(*vals)[i]).String()
+	}
+
+	return xs
+}
+
 
 func VariadicComplex64ValueConstructor(val reflect.Value) interfaces.VariadicValue {
 	return (*VariadicComplex64Value)(val.Interface().(*[]complex64))
@@ -808,6 +943,15 @@ func (val *VariadicComplex128Value) FlagValueDescription() string {
 	return "complex number(s)"
 }
 
+func (vals *VariadicComplex128Value) FormatValues() []string {
+	xs := make([]string, len(*vals))
+	for i := range *vals {
+		xs[i] = (*Complex128Value)(&(*vals)[i]).String()
+	}
+
+	return xs
+}
+
 
 func VariadicComplex128ValueConstructor(val reflect.Value) interfaces.VariadicValue {
 	return (*VariadicComplex128Value)(val.Interface().(*[]complex128))
@@ -58,6 +58,12 @@ func TestVariadicStringValue(t *testing.T) {
 	if !reflect.DeepEqual(x, []string{ "foo", "bar", "baz" }) {
 		t.Error("x holds the wrong value")
 	}
+
+	if f, ok := vv.(interfaces.VariadicValueFormatter); !ok {
+		t.Error("We should be able to format the values")
+	} else if err := vv.Set(f.FormatValues()); err != nil || !reflect.DeepEqual(x, []string{ "foo", "bar", "baz" }) {
+		t.Errorf("Formatted values do not parse back into the values: %v", f.FormatValues())
+	}
 }
 
 func TestBoolValue(t *testing.T) {
@@ -114,6 +120,12 @@ func TestVariadicBoolValue(t *testing.T) {
 		t.Error("x holds the wrong value")
 	}
 
+	if f, ok := vv.(interfaces.VariadicValueFormatter); !ok {
+		t.Error("We should be able to format the values")
+	} else if err := vv.Set(f.FormatValues()); err != nil || !reflect.DeepEqual(x, []bool{ true, false, true }) {
+		t.Errorf("Formatted values do not parse back into the values: %v", f.FormatValues())
+	}
+
 	if err := vv.Set([]string{ "foo" }); err == nil {
 		t.Error("vv.Set() should fail this time")
 	}
@@ -173,6 +185,12 @@ func TestVariadicIntValue(t *testing.T) {
 		t.Error("x holds the wrong value")
 	}
 
+	if f, ok := vv.(interfaces.VariadicValueFormatter); !ok {
+		t.Error("We should be able to format the values")
+	} else if err := vv.Set(f.FormatValues()); err != nil || !reflect.DeepEqual(x, []int{ -1, 2, -3 }) {
+		t.Errorf("Formatted values do not parse back into the values: %v", f.FormatValues())
+	}
+
 	if err := vv.Set([]string{ "foo" }); err == nil {
 		t.Error("vv.Set() should fail this time")
 	}
@@ -232,6 +250,12 @@ func TestVariadicInt8Value(t *testing.T) {
 		t.Error("x holds the wrong value")
 	}
 
+	if f, ok := vv.(interfaces.VariadicValueFormatter); !ok {
+		t.Error("We should be able to format the values")
+	} else if err := vv.Set(f.FormatValues()); err != nil || !reflect.DeepEqual(x, []int8{ -1, 2, -3 }) {
+		t.Errorf("Formatted values do not parse back into the values: %v", f.FormatValues())
+	}
+
 	if err := vv.Set([]string{ "foo" }); err == nil {
 		t.Error("vv.Set() should fail this time")
 	}
@@ -291,6 +315,12 @@ func TestVariadicInt16Value(t *testing.T) {
 		t.Error("x holds the wrong value")
 	}
 
+	if f, ok := vv.(interfaces.VariadicValueFormatter); !ok {
+		t.Error("We should be able to format the values")
+	} else if err := vv.Set(f.FormatValues()); err != nil || !reflect.DeepEqual(x, []int16{ -1, 2, -3 }) {
+		t.Errorf("Formatted values do not parse back into the values: %v", f.FormatValues())
+	}
+
 	if err := vv.Set([]string{ "foo" }); err == nil {
 		t.Error("vv.Set() should fail this time")
 	}
@@ -350,6 +380,12 @@ func TestVariadicInt32Value(t *testing.T) {
 		t.Error("x holds the wrong value")
 	}
 
+	if f, ok := vv.(interfaces.VariadicValueFormatter); !ok {
+		t.Error("We should be able to format the values")
+	} else if err := vv.Set(f.FormatValues()); err != nil || !reflect.DeepEqual(x, []int32{ -1, 2, -3 }) {
+		t.Errorf("Formatted values do not parse back into the values: %v", f.FormatValues())
+	}
+
 	if err := vv.Set([]string{ "foo" }); err == nil {
 		t.Error("vv.Set() should fail this time")
 	}
@@ -409,6 +445,12 @@ func TestVariadicInt64Value(t *testing.T) {
 		t.Error("x holds the wrong value")
 	}
 
+	if f, ok := vv.(interfaces.VariadicValueFormatter); !ok {
+		t.Error("We should be able to format the values")
+	} else if err := vv.Set(f.FormatValues()); err != nil || !reflect.DeepEqual(x, []int64{ -1, 2, -3 }) {
+		t.Errorf("Formatted values do not parse back into the values: %v", f.FormatValues())
+	}
+
 	if err := vv.Set([]string{ "foo" }); err == nil {
 		t.Error("vv.Set() should fail this time")
 	}
@@ -468,6 +510,12 @@ func TestVariadicUintValue(t *testing.T) {
 		t.Error("x holds the wrong value")
 	}
 
+	if f, ok := vv.(interfaces.VariadicValueFormatter); !ok {
+		t.Error("We should be able to format the values")
+	} else if err := vv.Set(f.FormatValues()); err != nil || !reflect.DeepEqual(x, []uint{ 1, 2, 3 }) {
+		t.Errorf("Formatted values do not parse back into the values: %v", f.FormatValues())
+	}
+
 	if err := vv.Set([]string{ "-1" }); err == nil {
 		t.Error("vv.Set() should fail this time")
 	}
@@ -527,6 +575,12 @@ func TestVariadicUint8Value(t *testing.T) {
 		t.Error("x holds the wrong value")
 	}
 
+	if f, ok := vv.(interfaces.VariadicValueFormatter); !ok {
+		t.Error("We should be able to format the values")
+	} else if err := vv.Set(f.FormatValues()); err != nil || !reflect.DeepEqual(x, []uint8{ 1, 2, 3 }) {
+		t.Errorf("Formatted values do not parse back into the values: %v", f.FormatValues())
+	}
+
 	if err := vv.Set([]string{ "-1" }); err == nil {
 		t.Error("vv.Set() should fail this time")
 	}
@@ -586,6 +640,12 @@ func TestVariadicUint16Value(t *testing.T) {
 		t.Error("x holds the wrong value")
 	}
 
+	if f, ok := vv.(interfaces.VariadicValueFormatter); !ok {
+		t.Error("We should be able to format the values")
+	} else if err := vv.Set(f.FormatValues()); err != nil || !reflect.DeepEqual(x, []uint16{ 1, 2, 3 }) {
+		t.Errorf("Formatted values do not parse back into the values: %v", f.FormatValues())
+	}
+
 	if err := vv.Set([]string{ "-1" }); err == nil {
 		t.Error("vv.Set() should fail this time")
 	}
@@ -645,6 +705,12 @@ func TestVariadicUint32Value(t *testing.T) {
 		t.Error("x holds the wrong value")
 	}
 
+	if f, ok := vv.(interfaces.VariadicValueFormatter); !ok {
+		t.Error("We should be able to format the values")
+	} else if err := vv.Set(f.FormatValues()); err != nil || !reflect.DeepEqual(x, []uint32{ 1, 2, 3 }) {
+		t.Errorf("Formatted values do not parse back into the values: %v", f.FormatValues())
+	}
+
 	if err := vv.Set([]string{ "-1" }); err == nil {
 		t.Error("vv.Set() should fail this time")
 	}
@@ -704,6 +770,12 @@ func TestVariadicUint64Value(t *testing.T) {
 		t.Error("x holds the wrong value")
 	}
 
+	if f, ok := vv.(interfaces.VariadicValueFormatter); !ok {
+		t.Error("We should be able to format the values")
+	} else if err := vv.Set(f.FormatValues()); err != nil || !reflect.DeepEqual(x, []uint64{ 1, 2, 3 }) {
+		t.Errorf("Formatted values do not parse back into the values: %v", f.FormatValues())
+	}
+
 	if err := vv.Set([]string{ "-1" }); err == nil {
 		t.Error("vv.Set() should fail this time")
 	}
@@ -763,6 +835,12 @@ func TestVariadicFloat32Value(t *testing.T) {
 		t.Error("x holds the wrong value")
 	}
 
+	if f, ok := vv.(interfaces.VariadicValueFormatter); !ok {
+		t.Error("We should be able to format the values")
+	} else if err := vv.Set(f.FormatValues()); err != nil || !reflect.DeepEqual(x, []float32{ 0.1, 0.2, 0.3 }) {
+		t.Errorf("Formatted values do not parse back into the values: %v", f.FormatValues())
+	}
+
 	if err := vv.Set([]string{ "foo" }); err == nil {
 		t.Error("vv.Set() should fail this time")
 	}
@@ -822,6 +900,12 @@ func TestVariadicFloat64Value(t *testing.T) {
 		t.Error("x holds the wrong value")
 	}
 
+	if f, ok := vv.(interfaces.VariadicValueFormatter); !ok {
+		t.Error("We should be able to format the values")
+	} else if err := vv.Set(f.FormatValues()); err != nil || !reflect.DeepEqual(x, []float64{ 0.1, 0.2, 0.3 }) {
+		t.Errorf("Formatted values do not parse back into the values: %v", f.FormatValues())
+	}
+
 	if err := vv.Set([]string{ "foo" }); err == nil {
 		t.Error("vv.Set() should fail this time")
 	}
@@ -881,6 +965,12 @@ func TestVariadicComplex64Value(t *testing.T) {
 		t.Error("x holds the wrong value")
 	}
 
+	if f, ok := vv.(interfaces.VariadicValueFormatter); !ok {
+		t.Error("We should be able to format the values")
+	} else if err := vv.Set(f.FormatValues()); err != nil || !reflect.DeepEqual(x, []complex64{ 0.1+0.2i, 0.2+0.3i, 0.3+0.4i }) {
+		t.Errorf("Formatted values do not parse back into the values: %v", f.FormatValues())
+	}
+
 	if err := vv.Set([]string{ "foo" }); err == nil {
 		t.Error("vv.Set() should fail this time")
 	}
@@ -940,6 +1030,12 @@ func TestVariadicComplex128Value(t *testing.T) {
 		t.Error("x holds the wrong value")
 	}
 
+	if f, ok := vv.(interfaces.VariadicValueFormatter); !ok {
+		t.Error("We should be able to format the values")
+	} else if err := vv.Set(f.FormatValues()); err != nil || !reflect.DeepEqual(x, []complex128{ 0.1+0.2i, 0.2+0.3i, 0.3+0.4i }) {
+		t.Errorf("Formatted values do not parse back into the values: %v", f.FormatValues())
+	}
+
 	if err := vv.Set([]string{ "foo" }); err == nil {
 		t.Error("vv.Set() should fail this time")
 	}
@@ -0,0 +1,247 @@
+package cli
+
+import (
+	"context"
+
+	"github.com/mailund/cli/interfaces"
+)
+
+// Invocation is a parsed command line. It holds the commands the command line
+// selects, from the top-level command down to the command that should handle
+// it, together with their parsed arguments.
+type Invocation struct {
+	// Levels holds one entry per command on the command path, starting with
+	// the top-level command.
+	Levels []*Level
+}
+
+// Level is a command in an invocation, together with its arguments.
+type Level struct {
+	Command *Command    // The command
+	Argv    interface{} // The parsed argv for the command, as returned by its Init function
+	Args    []string    // The raw arguments the command parsed, with the arguments for subcommands
+
+	inst   *Command // the instance of the command that holds the parsed values
+	closed bool     // set when the level's values are closed
+}
+
+// Source reports where the flag or positional argument with the given name
+// got its value from, see Command.Source.
+func (lvl *Level) Source(name string) (interfaces.Provenance, bool) {
+	return lvl.inst.source(name)
+}
+
+// IsSet reports whether the flag or positional argument with the given name
+// was explicitly given a value, see Command.IsSet.
+func (lvl *Level) IsSet(name string) bool {
+	prov, ok := lvl.Source(name)
+	return ok && prov.Source != interfaces.SourceDefault
+}
+
+// Path returns the names of the commands in the invocation, starting with
+// the top-level command.
+func (inv *Invocation) Path() []string {
+	return inv.path(len(inv.Levels))
+}
+
+// path returns the names of the first n commands in the invocation.
+func (inv *Invocation) path(n int) []string {
+	path := make([]string, n)
+	for i := range path {
+		path[i] = inv.Levels[i].Command.Name
+	}
+
+	return path
+}
+
+// Leaf returns the last level of the invocation, the command that should
+// handle the command line.
+func (inv *Invocation) Leaf() *Level {
+	return inv.Levels[len(inv.Levels)-1]
+}
+
+// Close closes the values that parsing opened, see the Closing files section
+// in the documentation. Running a command closes them automatically, but if
+// you only parse a command line, you should close the invocation when you
+// are done with it. It is safe to call Close more than once.
+func (inv *Invocation) Close() error {
+	return inv.closeLevels(0)
+}
+
+// closeLevels closes the values of the levels from the innermost to level
+// from, and returns the first error.
+func (inv *Invocation) closeLevels(from int) error {
+	var err error
+
+	for i := len(inv.Levels) - 1; i >= from; i-- {
+		lvl := inv.Levels[i]
+		if lvl.closed {
+			continue
+		}
+
+		lvl.closed = true
+
+		if cerr := lvl.inst.closeValues(); cerr != nil && err == nil {
+			err = cerr
+		}
+	}
+
+	return err
+}
+
+// parseInvocation parses the command line for the command and the subcommands
+// it selects, with instances of the commands from newInstance. If parsing fails,
+// it closes the values it opened and returns the instance of the command where
+// it failed together with the error.
+func (cmd *Command) parseInvocation(args []string,
+	newInstance func(*Command) (*Command, error)) (inv *Invocation, failed *Command, err error) {
+	inv = &Invocation{}
+
+	defer func() {
+		if err != nil {
+			_ = inv.Close() // the parse error is more informative than any close errors
+		}
+	}()
+
+	for c, path := cmd, []string{cmd.Name}; ; {
+		inst, err := newInstance(c)
+		if err != nil {
+			return inv, c, err
+		}
+
+		inv.Levels = append(inv.Levels, &Level{Command: c, Args: args, inst: inst})
+
+		err = inst.parse(path, args)
+		c.setRecent(inst)
+
+		if err != nil {
+			return inv, inst, err
+		}
+
+		inv.Leaf().Argv = inst.argv
+
+		if len(inst.subcommands) == 0 {
+			return inv, nil, nil
+		}
+
+		sub, ok := inst.subcommands[inst.command]
+		if !ok {
+			perr := interfaces.NewParseError(interfaces.KindInvalidCommand, nil,
+				"'%s' is not a valid command for %s", inst.command, inst.Name)
+			perr.Name, perr.Token, perr.Path = "cmd", inst.command, path
+			perr.Index = len(args) - len(inst.cmdArgs) - 1
+
+			if inst.collectErrors {
+				return inv, inst, interfaces.ParseErrors{perr}
+			}
+
+			return inv, inst, perr
+		}
+
+		c, args = sub, inst.cmdArgs
+		path = append(append([]string{}, path...), sub.Name)
+	}
+}
+
+// usesContext reports whether any of the commands in the invocation can see the
+// context it runs with, through an ActionContext or a hook.
+func (inv *Invocation) usesContext() bool {
+	for _, lvl := range inv.Levels {
+		c := lvl.inst
+		for _, hook := range []Hook{
+			c.PreRun, c.PostRun, c.Finally, c.PersistentPreRun, c.PersistentPostRun, c.PersistentFinally,
+		} {
+			if hook != nil {
+				return true
+			}
+		}
+
+		if c.ActionContext != nil {
+			return true
+		}
+	}
+
+	return false
+}
+
+// run runs the commands in the invocation from level i and down, and returns
+// the command where an error occurred together with the error.
+func (inv *Invocation) run(ctx context.Context, i int) (failed *Command, err error) {
+	cmd := inv.Levels[i].inst
+
+	// Close the values we opened when we are done. This is deferred first, so
+	// it happens after the finally hooks. It also closes the values of the
+	// subcommands if we never get to run them.
+	defer func() {
+		if cerr := inv.closeLevels(i); cerr != nil && err == nil {
+			failed, err = cmd, cerr
+		}
+	}()
+
+	ctx = withCommand(ctx, inv.path(i+1), cmd)
+
+	// The persistent hooks of all the commands on the path run once, around
+	// the command that handles the command line.
+	var chain []*Command
+
+	if i == len(inv.Levels)-1 {
+		chain = make([]*Command, len(inv.Levels))
+		for j := range chain {
+			chain[j] = inv.Levels[j].inst
+		}
+	}
+
+	defer func() {
+		ferr := cmd.callHook(ctx, cmd.Finally)
+		if perr := persistentFinally(ctx, chain); ferr == nil {
+			ferr = perr
+		}
+
+		if ferr != nil && err == nil {
+			failed, err = cmd, ferr
+		}
+	}()
+
+	if err := persistentPreRun(ctx, chain); err != nil {
+		return cmd, err
+	}
+
+	if err := cmd.callHook(ctx, cmd.PreRun); err != nil {
+		return cmd, err
+	}
+
+	// Invoke the action for this (sub)command
+	if err := cmd.action(ctx, cmd.argv); err != nil {
+		return cmd, err
+	}
+
+	// then, if there are sub-commands, dispatch
+	if i+1 < len(inv.Levels) {
+		if failed, err := inv.run(ctx, i+1); err != nil {
+			return failed, err
+		}
+	}
+
+	if err := cmd.callHook(ctx, cmd.PostRun); err != nil {
+		return cmd, err
+	}
+
+	return cmd, persistentPostRun(ctx, chain)
+}
+
+// Parse parses the command line in args, for the command and the subcommands
+// the command line selects, without running any actions or hooks. If the command
+// line asks for help, the usage is printed and Parse returns ErrHelp. Parsing
+// can open files, so you should Close the invocation when you are done with it.
+func (cmd *Command) Parse(args []string) (*Invocation, error) {
+	inv, _, err := cmd.parseInvocation(args, (*Command).instance)
+
+	switch {
+	case isHelp(err):
+		return nil, ErrHelp
+	case err != nil:
+		return nil, err
+	default:
+		return inv, nil
+	}
+}
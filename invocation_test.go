@@ -0,0 +1,164 @@
+package cli_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/mailund/cli"
+	"github.com/mailund/cli/interfaces"
+)
+
+func TestParse(t *testing.T) {
+	type (
+		CmdArgs struct {
+			Verbose bool `flag:"verbose" short:"v"`
+		}
+		SubArgs struct {
+			N  int      `flag:"n"`
+			Xs []string `pos:"xs"`
+		}
+	)
+
+	noAction := func(interface{}) { t.Error("Parse should not run actions") }
+	noHook := func(context.Context, interface{}) error {
+		t.Error("Parse should not run hooks")
+		return nil
+	}
+
+	sub := cli.NewCommand(cli.CommandSpec{
+		Name:    "sub",
+		Init:    func() interface{} { return new(SubArgs) },
+		Action:  noAction,
+		PreRun:  noHook,
+		Finally: noHook,
+	})
+	cmd := cli.NewCommand(cli.CommandSpec{
+		Name:        "cmd",
+		Init:        func() interface{} { return new(CmdArgs) },
+		Action:      noAction,
+		Subcommands: []*cli.Command{sub},
+	})
+
+	inv, err := cmd.Parse([]string{"-v", "sub", "-n", "42", "a", "b"})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	defer inv.Close()
+
+	if !reflect.DeepEqual(inv.Path(), []string{"cmd", "sub"}) {
+		t.Errorf("unexpected path: %v", inv.Path())
+	}
+
+	top, leaf := inv.Levels[0], inv.Leaf()
+	if top.Command != cmd || leaf.Command.Name != "sub" {
+		t.Errorf("unexpected commands: %s, %s", top.Command.Name, leaf.Command.Name)
+	}
+
+	if !reflect.DeepEqual(leaf.Args, []string{"-n", "42", "a", "b"}) {
+		t.Errorf("unexpected args for sub: %v", leaf.Args)
+	}
+
+	if v := reflect.ValueOf(top.Argv).Elem().FieldByName("Verbose"); !v.Bool() {
+		t.Error("expected verbose to be set")
+	}
+
+	argv := reflect.ValueOf(leaf.Argv).Elem()
+	if argv.FieldByName("N").Int() != 42 || argv.FieldByName("Xs").Len() != 2 {
+		t.Errorf("unexpected argv for sub: %v", leaf.Argv)
+	}
+
+	if !top.IsSet("v") || !leaf.IsSet("n") || !leaf.IsSet("xs") || leaf.IsSet("unknown") {
+		t.Error("unexpected IsSet results")
+	}
+
+	if src, _ := leaf.Source("n"); src.Source != interfaces.SourceCommandLine || src.Raw != "42" {
+		t.Errorf("unexpected source for n: %v", src)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	sub := cli.NewCommand(cli.CommandSpec{
+		Name: "sub",
+		Init: func() interface{} {
+			return new(struct {
+				N int `flag:"n"`
+			})
+		},
+	})
+	cmd, _ := withOutput(cli.NewCommand(cli.CommandSpec{Name: "cmd", Subcommands: []*cli.Command{sub}}))
+
+	var perr *interfaces.ParseError
+
+	_, err := cmd.Parse([]string{"sub", "-n", "foo"})
+	if !errors.As(err, &perr) || perr.Kind != interfaces.KindConversion {
+		t.Errorf("expected a conversion error, got %v", err)
+	}
+
+	_, err = cmd.Parse([]string{"foo"})
+	if !errors.As(err, &perr) || perr.Kind != interfaces.KindInvalidCommand {
+		t.Errorf("expected an invalid command error, got %v", err)
+	}
+
+	if _, err = cmd.Parse([]string{"sub", "-h"}); !errors.Is(err, cli.ErrHelp) {
+		t.Errorf("expected ErrHelp, got %v", err)
+	}
+}
+
+func TestParseCloses(t *testing.T) {
+	type Args struct {
+		Val closeValue `flag:"val"`
+		Pos closeValue `pos:"pos"`
+	}
+
+	argv := new(Args)
+	cmd := cli.NewCommand(cli.CommandSpec{
+		Name: "cmd",
+		Init: func() interface{} { return argv },
+	})
+
+	inv, err := cmd.Parse([]string{"--val", "x", "y"})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if argv.Val.closed || argv.Pos.closed {
+		t.Error("Parse should leave the values open")
+	}
+
+	if err := inv.Close(); err != nil || !argv.Val.closed || !argv.Pos.closed {
+		t.Errorf("Close should close the values (err: %v)", err)
+	}
+
+	argv.Val.closed = false
+	if err := inv.Close(); err != nil || argv.Val.closed {
+		t.Error("closing twice should not close the values again")
+	}
+}
+
+func TestParentActionAfterParse(t *testing.T) {
+	parentRan := false
+
+	sub := cli.NewCommand(cli.CommandSpec{
+		Name: "sub",
+		Init: func() interface{} {
+			return new(struct {
+				N int `flag:"n"`
+			})
+		},
+	})
+	cmd := cli.NewCommand(cli.CommandSpec{
+		Name:        "cmd",
+		Action:      func(interface{}) { parentRan = true },
+		Subcommands: []*cli.Command{sub},
+	})
+
+	if err := cmd.RunError([]string{"sub", "-n", "foo"}); err == nil {
+		t.Fatal("expected a parse error")
+	}
+
+	if parentRan {
+		t.Error("no action should run if any part of the command line is invalid")
+	}
+}
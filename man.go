@@ -0,0 +1,184 @@
+package cli
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// roffEscape escapes text so roff prints it as it is.
+func roffEscape(x string) string {
+	x = strings.ReplaceAll(x, `\`, `\e`)
+	x = strings.ReplaceAll(x, "-", `\-`)
+
+	lines := strings.Split(x, "\n")
+	for i, line := range lines {
+		if strings.HasPrefix(line, ".") || strings.HasPrefix(line, "'") {
+			lines[i] = `\&` + line
+		}
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// manName is the name of the man page for the command with the given path.
+func manName(path []string) string {
+	return strings.Join(path, "-")
+}
+
+func (cmd *Command) writeManPage(w io.Writer, path []string) {
+	name := manName(path)
+
+	fmt.Fprintf(w, ".TH \"%s\" \"1\"\n", roffEscape(strings.ToUpper(name)))
+
+	fmt.Fprintln(w, ".SH NAME")
+
+	if cmd.Short != "" {
+		fmt.Fprintf(w, "%s \\- %s\n", roffEscape(name), roffEscape(cmd.Short))
+	} else {
+		fmt.Fprintln(w, roffEscape(name))
+	}
+
+	fmt.Fprintln(w, ".SH SYNOPSIS")
+	fmt.Fprintf(w, ".B %s\n", roffEscape(strings.Join(path, " ")))
+	fmt.Fprintln(w, roffEscape(strings.TrimSpace("[flags] "+cmd.params.ShortUsage())))
+
+	if cmd.Long != "" {
+		fmt.Fprintln(w, ".SH DESCRIPTION")
+		for _, line := range strings.Split(wordWrap(cmd.Long, noWrap), "\n") {
+			if line == "" {
+				fmt.Fprintln(w, ".PP")
+			} else {
+				fmt.Fprintln(w, roffEscape(line))
+			}
+		}
+	}
+
+	usage := cmd.UsageData()
+
+	if len(usage.Flags) > 0 {
+		fmt.Fprintln(w, ".SH OPTIONS")
+
+		for _, f := range usage.Flags {
+			names := []string{}
+			for _, n := range strings.Split(f.Names, ",") {
+				names = append(names, `\fB`+roffEscape(n)+`\fR`)
+			}
+
+			fmt.Fprintln(w, ".TP")
+			fmt.Fprintf(w, "%s%s\n", strings.Join(names, ", "), roffEscape(surround(" ", f.Value, "")))
+			fmt.Fprintln(w, roffEscape(f.Description+surround(" (default ", f.Default, ")")))
+		}
+	}
+
+	if len(usage.Params) > 0 {
+		fmt.Fprintln(w, ".SH ARGUMENTS")
+
+		for _, p := range usage.Params {
+			fmt.Fprintf(w, ".TP\n\\fI%s\\fR\n%s\n", roffEscape(p.Name), roffEscape(p.Description))
+		}
+	}
+
+	if len(cmd.Examples) > 0 {
+		fmt.Fprintln(w, ".SH EXAMPLES")
+
+		for _, ex := range cmd.Examples {
+			fmt.Fprintf(w, ".TP\n\\fB%s\\fR\n%s\n", roffEscape(ex.Cmdline), roffEscape(ex.Description))
+		}
+	}
+
+	subs := cmd.visibleSubcommands()
+	if len(subs) > 0 {
+		fmt.Fprintln(w, ".SH COMMANDS")
+
+		for _, sub := range subs {
+			fmt.Fprintf(w, ".TP\n\\fB%s\\fR\n%s\n", roffEscape(sub.Name), roffEscape(sub.Short))
+		}
+	}
+
+	seeAlso := []string{}
+	if len(path) > 1 {
+		seeAlso = append(seeAlso, manName(path[:len(path)-1]))
+	}
+
+	for _, sub := range subs {
+		seeAlso = append(seeAlso, manName(subPath(path, sub.Name)))
+	}
+
+	if len(seeAlso) > 0 {
+		fmt.Fprintln(w, ".SH SEE ALSO")
+
+		refs := make([]string, len(seeAlso))
+		for i, ref := range seeAlso {
+			refs[i] = `\fB` + roffEscape(ref) + `\fR(1)`
+		}
+
+		fmt.Fprintln(w, strings.Join(refs, ",\n"))
+	}
+}
+
+// WriteManPage writes a section 1 man page for cmd, as a top-level command, to w.
+// The page has no date, so the output only changes when the command does.
+func (cmd *Command) WriteManPage(w io.Writer) error {
+	var buf bytes.Buffer
+
+	cmd.writeManPage(&buf, []string{cmd.Name})
+	_, err := w.Write(buf.Bytes())
+
+	return err
+}
+
+func (cmd *Command) writeManPages(dir string, path []string) error {
+	var buf bytes.Buffer
+
+	cmd.writeManPage(&buf, path)
+
+	fname := filepath.Join(dir, manName(path)+".1")
+	if err := ioutil.WriteFile(fname, buf.Bytes(), 0o644); err != nil {
+		return err
+	}
+
+	for _, sub := range cmd.visibleSubcommands() {
+		if err := sub.writeManPages(dir, subPath(path, sub.Name)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// WriteManPages writes a section 1 man page for cmd and each of its (visible)
+// subcommands to the directory dir, which is created if it doesn't exist.
+// A subcommand's page is named after its path, e.g. tool-calc-add.1.
+func (cmd *Command) WriteManPages(dir string) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+
+	return cmd.writeManPages(dir, []string{cmd.Name})
+}
+
+// ManCommand returns a hidden command, named gen-man, that writes the man pages
+// for root to the directory given as its argument. Add it to root with
+// AddSubcommands.
+func ManCommand(root *Command) *Command {
+	type manArgs struct {
+		Dir string `pos:"dir" descr:"directory to write the man pages to"`
+	}
+
+	return NewCommand(CommandSpec{
+		Name:   "gen-man",
+		Short:  "write man pages",
+		Long:   "Writes man pages for " + root.Name + " and its commands to a directory.",
+		Hidden: true,
+		Init:   func() interface{} { return new(manArgs) },
+		ActionError: func(i interface{}) error {
+			args, _ := i.(*manArgs)
+			return root.WriteManPages(args.Dir)
+		},
+	})
+}
@@ -0,0 +1,89 @@
+package cli_test
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mailund/cli"
+)
+
+func TestWriteManPage(t *testing.T) {
+	root, _ := toolTree(t)
+
+	var page strings.Builder
+	if err := root.WriteManPage(&page); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	checkContains(t, page.String(),
+		".TH \"TOOL\" \"1\"\n",
+		".SH SYNOPSIS\n.B tool\n[flags] cmd ...\n",
+		".TP\n\\fB\\-m\\fR, \\fB\\-\\-mode\\fR {fast,slow}\nthe mode (default fast)\n",
+		".TP\n\\fBcalc\\fR\na calculator\n",
+		".SH SEE ALSO\n\\fBtool\\-calc\\fR(1)\n")
+
+	if strings.Contains(page.String(), "secret") || strings.Contains(page.String(), "completion") {
+		t.Errorf("hidden commands should not be in the man page:\n%s", page.String())
+	}
+}
+
+func TestManCommand(t *testing.T) {
+	root, _ := toolTree(t)
+	if err := root.AddSubcommands(cli.ManCommand(root)); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	dir := t.TempDir()
+	if err := root.RunError([]string{"gen-man", dir}); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	files, _ := filepath.Glob(filepath.Join(dir, "*"))
+	for i := range files {
+		files[i] = filepath.Base(files[i])
+	}
+
+	if got, want := strings.Join(files, " "), "tool-calc-add.1 tool-calc.1 tool.1"; got != want {
+		t.Fatalf("expected pages %q, got %q", want, got)
+	}
+
+	page, err := ioutil.ReadFile(filepath.Join(dir, "tool-calc-add.1"))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	checkContains(t, string(page),
+		".SH NAME\ntool\\-calc\\-add \\- add things\n",
+		".B tool calc add\n[flags] file\n",
+		".SH SEE ALSO\n\\fBtool\\-calc\\fR(1)\n")
+
+	// the output is deterministic
+	if err := root.RunError([]string{"gen-man", dir}); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	page2, _ := ioutil.ReadFile(filepath.Join(dir, "tool-calc-add.1"))
+	if string(page) != string(page2) {
+		t.Errorf("man pages differ between runs")
+	}
+}
+
+func TestRoffEscapes(t *testing.T) {
+	cmd := cli.NewCommand(cli.CommandSpec{
+		Name:  "tool",
+		Short: `back\slash`,
+		Long:  ".dot first\n\nsecond paragraph",
+	})
+
+	var page strings.Builder
+	if err := cmd.WriteManPage(&page); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	checkContains(t, page.String(),
+		`tool \- back\eslash`,
+		".SH DESCRIPTION\n\\&.dot first\n.PP\nsecond paragraph\n",
+		".B tool\n[flags]\n")
+}
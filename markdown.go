@@ -0,0 +1,150 @@
+package cli
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// mdLink returns the link target for the documentation of the command with
+// the given path.
+type mdLink func(path []string) string
+
+// mdAnchor links to a section in the same document.
+func mdAnchor(path []string) string {
+	return "#" + manName(path)
+}
+
+// mdPage links to a separate page.
+func mdPage(path []string) string {
+	return manName(path) + ".md"
+}
+
+// mdEscape escapes the characters that Markdown would otherwise interpret
+// in running text.
+func mdEscape(x string) string {
+	r := strings.NewReplacer(`\`, `\\`, "*", `\*`, "_", `\_`, "`", "\\`", "[", `\[`, "]", `\]`, "<", `\<`, ">", `\>`)
+	return r.Replace(x)
+}
+
+func subPath(path []string, name string) []string {
+	return append(append([]string{}, path...), name)
+}
+
+func (cmd *Command) writeMarkdown(w io.Writer, path []string, link mdLink) {
+	fmt.Fprintf(w, "<a id=\"%s\"></a>\n\n", manName(path))
+	fmt.Fprintf(w, "## %s\n\n", strings.Join(path, " "))
+
+	if len(path) > 1 {
+		parent := path[:len(path)-1]
+		fmt.Fprintf(w, "Part of [%s](%s).\n\n", strings.Join(parent, " "), link(parent))
+	}
+
+	fmt.Fprintf(w, "```\n%s\n```\n\n", strings.TrimSpace(strings.Join(path, " ")+" [flags] "+cmd.params.ShortUsage()))
+
+	if cmd.Long != "" {
+		fmt.Fprintf(w, "%s\n\n", mdEscape(wordWrap(cmd.Long, noWrap)))
+	} else if cmd.Short != "" {
+		fmt.Fprintf(w, "%s\n\n", mdEscape(cmd.Short))
+	}
+
+	usage := cmd.UsageData()
+
+	if len(usage.Flags) > 0 {
+		fmt.Fprintf(w, "### Flags\n\n")
+
+		for _, f := range usage.Flags {
+			fmt.Fprintf(w, "- `%s%s`: %s\n", f.Names, surround(" ", f.Value, ""),
+				mdEscape(f.Description+surround(" (default ", f.Default, ")")))
+		}
+
+		fmt.Fprintln(w)
+	}
+
+	if len(usage.Params) > 0 {
+		fmt.Fprintf(w, "### Arguments\n\n")
+
+		for _, p := range usage.Params {
+			fmt.Fprintf(w, "- `%s`: %s\n", p.Name, mdEscape(p.Description))
+		}
+
+		fmt.Fprintln(w)
+	}
+
+	if len(cmd.Examples) > 0 {
+		fmt.Fprintf(w, "### Examples\n\n")
+
+		for _, ex := range cmd.Examples {
+			if ex.Description != "" {
+				fmt.Fprintf(w, "%s\n\n", mdEscape(ex.Description))
+			}
+
+			fmt.Fprintf(w, "```\n%s\n```\n\n", ex.Cmdline)
+		}
+	}
+
+	if subs := cmd.visibleSubcommands(); len(subs) > 0 {
+		fmt.Fprintf(w, "### Commands\n\n")
+
+		for _, sub := range subs {
+			fmt.Fprintf(w, "- [%s](%s): %s\n", sub.Name, link(subPath(path, sub.Name)), mdEscape(sub.Short))
+		}
+
+		fmt.Fprintln(w)
+	}
+}
+
+func (cmd *Command) writeMarkdownTree(w io.Writer, path []string) {
+	cmd.writeMarkdown(w, path, mdAnchor)
+
+	for _, sub := range cmd.visibleSubcommands() {
+		sub.writeMarkdownTree(w, subPath(path, sub.Name))
+	}
+}
+
+// WriteMarkdown writes reference documentation for cmd, as a top-level command,
+// and all its (visible) subcommands to w, as a single Markdown document. Each
+// command gets a section, with an anchor named after its path, e.g.
+// tool-calc-add, and commands link to their parents and subcommands.
+func (cmd *Command) WriteMarkdown(w io.Writer) error {
+	var buf bytes.Buffer
+
+	cmd.writeMarkdownTree(&buf, []string{cmd.Name})
+	_, err := w.Write(buf.Bytes())
+
+	return err
+}
+
+func (cmd *Command) writeMarkdownPages(dir string, path []string) error {
+	var buf bytes.Buffer
+
+	cmd.writeMarkdown(&buf, path, mdPage)
+
+	if err := ioutil.WriteFile(filepath.Join(dir, mdPage(path)), buf.Bytes(), 0o644); err != nil {
+		return err
+	}
+
+	for _, sub := range cmd.visibleSubcommands() {
+		if err := sub.writeMarkdownPages(dir, subPath(path, sub.Name)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// WriteMarkdownPages writes a Markdown page for cmd and each of its (visible)
+// subcommands to the directory dir, which is created if it doesn't exist.
+// The pages are named after the commands' paths, e.g. tool-calc-add.md, and
+// link to each other.
+func (cmd *Command) WriteMarkdownPages(dir string) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+
+	return cmd.writeMarkdownPages(dir, []string{cmd.Name})
+}
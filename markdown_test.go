@@ -0,0 +1,55 @@
+package cli_test
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestWriteMarkdown(t *testing.T) {
+	root, _ := toolTree(t)
+
+	var doc strings.Builder
+	if err := root.WriteMarkdown(&doc); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	checkContains(t, doc.String(),
+		"<a id=\"tool\"></a>\n\n## tool\n\n```\ntool [flags] cmd ...\n```\n",
+		"- `-m,--mode {fast,slow}`: the mode (default fast)\n",
+		"- [calc](#tool-calc): a calculator\n",
+		"<a id=\"tool-calc-add\"></a>\n\n## tool calc add\n\nPart of [tool calc](#tool-calc).\n",
+		"- `file`: \n")
+
+	if strings.Contains(doc.String(), "secret") || strings.Contains(doc.String(), "## tool completion") {
+		t.Errorf("hidden commands should not be documented:\n%s", doc.String())
+	}
+}
+
+func TestWriteMarkdownPages(t *testing.T) {
+	root, _ := toolTree(t)
+
+	dir := t.TempDir()
+	if err := root.WriteMarkdownPages(dir); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	files, _ := filepath.Glob(filepath.Join(dir, "*"))
+	for i := range files {
+		files[i] = filepath.Base(files[i])
+	}
+
+	if got, want := strings.Join(files, " "), "tool-calc-add.md tool-calc.md tool.md"; got != want {
+		t.Fatalf("expected pages %q, got %q", want, got)
+	}
+
+	page, err := ioutil.ReadFile(filepath.Join(dir, "tool-calc.md"))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	checkContains(t, string(page),
+		"Part of [tool](tool.md).\n",
+		"- [add](tool-calc-add.md): add things\n")
+}
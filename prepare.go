@@ -1,8 +1,6 @@
 package cli
 
-import (
-	"github.com/mailund/cli/interfaces"
-)
+import "github.com/mailund/cli/interfaces"
 
 func prepare(i interface{}) error {
 	if v, ok := i.(interfaces.Prepare); ok {
@@ -12,44 +10,53 @@ func prepare(i interface{}) error {
 	return nil
 }
 
-func prepareFlagsAndParams(cmd *Command) error {
-	var err error
+// prepareError creates an error for the flag or argument name, described as what.
+func prepareError(name, what string, cause error) error {
+	err := interfaces.NewParseError(interfaces.KindPrepare, cause, "error in %s: %s", what, cause)
+	err.Name = name
 
-	for i := 0; i < cmd.flags.NFlags(); i++ {
-		if err = prepare(cmd.flags.Flag(i).Value); err == nil {
-			continue
-		}
-
-		// we have an error, but need a better error message
-		f := cmd.flags.Flag(i)
-		flagname := ""
+	return err
+}
 
-		if f.Short != "" {
-			flagname += "-" + f.Short
-		}
+// prepareFlagsAndParams prepares all flags and parameters. If all is false,
+// it returns the first error it encounters, otherwise it returns all the
+// errors as interfaces.ParseErrors.
+func prepareFlagsAndParams(cmd *Command, all bool) error {
+	var errs interfaces.ParseErrors
 
-		if f.Long != "" {
-			if f.Short != "" {
-				flagname += ","
+	for i := 0; i < cmd.flags.NFlags(); i++ {
+		f := cmd.flags.Flag(i)
+		if err := prepare(f.Value); err != nil {
+			// we have an error, but need a better error message
+			errs = append(errs, prepareError(f.Name(), "flag "+f.Names(), err))
+			if !all {
+				return errs[0]
 			}
-
-			flagname += "--" + f.Long
 		}
-
-		return interfaces.ParseErrorf("error in flag %s: %s", flagname, err)
 	}
 
 	for i := 0; i < cmd.params.NParams(); i++ {
-		if err = prepare(cmd.params.Param(i).Value); err != nil {
-			return interfaces.ParseErrorf("error in argument %s: %s", cmd.params.Param(i).Name, err)
+		p := cmd.params.Param(i)
+		if err := prepare(p.Value); err != nil {
+			errs = append(errs, prepareError(p.Name, "argument "+p.Name, err))
+			if !all {
+				return errs[0]
+			}
 		}
 	}
 
 	if vv := cmd.params.Variadic(); vv != nil {
 		if err := prepare(vv.Value); err != nil {
-			return interfaces.ParseErrorf("error in argument %s: %s", cmd.params.Variadic().Name, err)
+			errs = append(errs, prepareError(vv.Name, "argument "+vv.Name, err))
+			if !all {
+				return errs[0]
+			}
 		}
 	}
 
+	if len(errs) > 0 {
+		return errs
+	}
+
 	return nil
 }
@@ -7,7 +7,6 @@ import (
 
 	"github.com/mailund/cli"
 	"github.com/mailund/cli/interfaces"
-	"github.com/mailund/cli/internal/failure"
 )
 
 type prepareSuccess struct{}
@@ -87,7 +86,7 @@ func TestFlagFail(t *testing.T) {
 	}
 
 	failed := false
-	failure.Failure = func() { failed = true }
+	cmd.SetExitFunc(func(int) { failed = true })
 
 	err = cmd.RunError([]string{})
 	if err == nil {
@@ -105,7 +104,8 @@ func TestFlagFail(t *testing.T) {
 		t.Error("Expected a failure")
 	}
 
-	if expected, msg := "Error: error in flag -f,--flag: fail.\n", builder.String(); msg != expected {
+	expected := "Error: error in flag -f,--flag: fail.\n\nUsage:"
+	if msg := builder.String(); !strings.HasPrefix(msg, expected) {
 		t.Errorf("(2) unexpected msg: %s", msg)
 	}
 }
@@ -115,7 +115,7 @@ func checkPosFailure(t *testing.T, cmd *cli.Command) {
 
 	builder := new(strings.Builder)
 	failed := false
-	failure.Failure = func() { failed = true }
+	cmd.SetExitFunc(func(int) { failed = true })
 
 	err := cmd.RunError([]string{"a"})
 	if err == nil {
@@ -133,7 +133,8 @@ func checkPosFailure(t *testing.T, cmd *cli.Command) {
 		t.Error("Expected a failure")
 	}
 
-	if expected, msg := "Error: error in argument pos: fail.\n", builder.String(); msg != expected {
+	expected := "Error: error in argument pos: fail.\n\nUsage:"
+	if msg := builder.String(); !strings.HasPrefix(msg, expected) {
 		t.Errorf("(2) unexpected msg: %s", msg)
 	}
 }
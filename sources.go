@@ -0,0 +1,101 @@
+package cli
+
+import (
+	"os"
+
+	"github.com/mailund/cli/interfaces"
+	"github.com/mailund/cli/internal/flags"
+	"github.com/mailund/cli/internal/vals"
+)
+
+// ConfigLookup looks up the value for a flag in a configuration, for example one
+// read from a file. It gets the path of the command the flag belongs to, starting
+// with the top-level command, and the flag's name, its long name if it has one,
+// and reports whether the configuration has a value for it.
+type ConfigLookup func(path []string, name string) (value string, ok bool)
+
+// SetConfig sets the function that the command, and its subcommands, use to find
+// values for flags that are not given on the command line. A flag that isn't set
+// on the command line gets its value from its environment variable, see the env
+// tag, and otherwise from the configuration. Source reports which it came from.
+func (cmd *Command) SetConfig(lookup ConfigLookup) {
+	cmd.config = lookup
+}
+
+// configLookup returns the configuration for the command, which is the one
+// set on the command itself or on the nearest command above it, or nil.
+func (cmd *Command) configLookup() ConfigLookup {
+	for c := cmd; c != nil; c = c.parent {
+		if c.config != nil {
+			return c.config
+		}
+	}
+
+	return nil
+}
+
+// sourceError creates an error for a value for flag f that we couldn't set
+// from where, an environment variable or the configuration.
+func sourceError(f *flags.Flag, where string, cause error) error {
+	err := interfaces.NewParseError(interfaces.KindConversion, cause,
+		"parsing flag %s from %s: %s", f.Names(), where, cause)
+	err.Name = f.Name()
+
+	return err
+}
+
+// fromSources reports whether f can get its value from an environment variable
+// or the configuration. Only flags that hold values can; callbacks, including
+// the built-in help and version flags, would run as if given on the command line.
+func fromSources(f *flags.Flag) bool {
+	switch f.Value.(type) {
+	case vals.FuncNoValue, vals.FuncValue:
+		return false
+	default:
+		return f.Provenance.Source == interfaces.SourceDefault
+	}
+}
+
+// setFromSources sets the flags that the command line didn't set from their
+// environment variables or the command's configuration. If all is false, it
+// returns the first error it encounters, otherwise it returns all the errors
+// as interfaces.ParseErrors.
+func (cmd *Command) setFromSources(path []string, all bool) error {
+	var errs interfaces.ParseErrors
+
+	config := cmd.configLookup()
+
+	for i := 0; i < cmd.flags.NFlags(); i++ {
+		f := cmd.flags.Flag(i)
+		if !fromSources(f) {
+			continue
+		}
+
+		var err error
+
+		if value, ok := os.LookupEnv(f.Env); f.Env != "" && ok {
+			if serr := f.SetFrom(value, interfaces.SourceEnvironment); serr != nil {
+				err = sourceError(f, "environment variable "+f.Env, serr)
+			}
+		} else if config != nil {
+			if value, ok := config(path, f.Name()); ok {
+				if serr := f.SetFrom(value, interfaces.SourceConfig); serr != nil {
+					err = sourceError(f, "configuration", serr)
+				}
+			}
+		}
+
+		if err != nil {
+			errs = append(errs, err)
+			if !all {
+				return errs[0]
+			}
+		}
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+
+	return nil
+}
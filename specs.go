@@ -5,10 +5,62 @@ import (
 	"strconv"
 
 	"github.com/mailund/cli/interfaces"
+	"github.com/mailund/cli/internal/flags"
 	"github.com/mailund/cli/internal/vals"
 )
 
-func setFlag(cmd *Command, argv interface{}, name string, tfield *reflect.StructField, vfield *reflect.Value) error {
+// setFlagUsage handles the tags that modify how a flag is shown in usage. The
+// group is the flag group of the struct the flag is in, if it has one.
+func setFlagUsage(f *flags.Flag, name, group string, tfield *reflect.StructField) error {
+	f.MetaVar = tfield.Tag.Get("metavar")
+	f.DefDescr = tfield.Tag.Get("defaultdescr")
+
+	f.Group = group
+	if g, ok := tfield.Tag.Lookup("group"); ok {
+		f.Group = g
+	}
+
+	if show, ok := tfield.Tag.Lookup("showdefault"); ok {
+		b, err := strconv.ParseBool(show)
+		if err != nil {
+			serr := interfaces.SpecErrorf("unexpected showdefault value for flag %s: %s", name, show)
+			serr.Err = err
+
+			return serr
+		}
+
+		f.HideDefault = !b
+	}
+
+	return nil
+}
+
+// completer returns the completion function for a field: the method on argv that
+// the field's complete tag names, or the value's own Complete method if it is a
+// Completer. It returns nil if the field doesn't have completion.
+func completer(argv, val interface{}, tfield *reflect.StructField) (func(string) []string, error) {
+	if name, ok := tfield.Tag.Lookup("complete"); ok {
+		method := reflect.ValueOf(argv).MethodByName(name)
+		if !method.IsValid() {
+			return nil, interfaces.SpecErrorf("no completion method %s for %s", name, tfield.Name)
+		}
+
+		complete, ok := method.Interface().(func(string) []string)
+		if !ok {
+			return nil, interfaces.SpecErrorf("incorrect signature for completion method %s: %q", name, method.Type())
+		}
+
+		return complete, nil
+	}
+
+	if c, ok := val.(interfaces.Completer); ok {
+		return c.Complete, nil
+	}
+
+	return nil, nil
+}
+
+func setFlag(cmd *Command, argv interface{}, name, group string, tfield *reflect.StructField, vfield *reflect.Value) error {
 	val := vals.AsFlagValue(vfield.Addr())
 	if val == nil {
 		val = vals.AsCallback(vfield, argv)
@@ -20,7 +72,19 @@ func setFlag(cmd *Command, argv interface{}, name string, tfield *reflect.Struct
 			short = name
 		}
 
-		return cmd.flags.Var(val, name, short, tfield.Tag.Get("descr"))
+		complete, err := completer(argv, val, tfield)
+		if err != nil {
+			return err
+		}
+
+		if err := cmd.flags.Var(val, name, short, tfield.Tag.Get("descr")); err != nil {
+			return err
+		}
+
+		f := cmd.flags.Flag(cmd.flags.NFlags() - 1)
+		f.Complete, f.Env = complete, tfield.Tag.Get("env")
+
+		return setFlagUsage(f, name, group, tfield)
 	}
 
 	// report appropriate error...
@@ -31,7 +95,7 @@ func setFlag(cmd *Command, argv interface{}, name string, tfield *reflect.Struct
 	return interfaces.SpecErrorf("unsupported type for flag %s: %q", name, tfield.Type.Kind())
 }
 
-func setVariadic(cmd *Command, name string, val interfaces.VariadicValue, tfield *reflect.StructField) error {
+func setVariadic(cmd *Command, argv interface{}, name string, val interfaces.VariadicValue, tfield *reflect.StructField) error {
 	if len(cmd.Subcommands) > 0 {
 		return interfaces.SpecErrorf("a command with subcommands cannot have variadic parameters")
 	}
@@ -48,10 +112,19 @@ func setVariadic(cmd *Command, name string, val interfaces.VariadicValue, tfield
 	if minTag := tfield.Tag.Get("min"); minTag == "" {
 		min = 0
 	} else if min, err = strconv.Atoi(tfield.Tag.Get("min")); err != nil {
-		return interfaces.SpecErrorf("unexpected min value for variadic parameter %s: %s", name, minTag)
+		serr := interfaces.SpecErrorf("unexpected min value for variadic parameter %s: %s", name, minTag)
+		serr.Err = err
+
+		return serr
+	}
+
+	complete, err := completer(argv, val, tfield)
+	if err != nil {
+		return err
 	}
 
 	cmd.params.VariadicVar(val, name, tfield.Tag.Get("descr"), min)
+	cmd.params.Variadic().Complete = complete
 
 	return nil
 }
@@ -65,17 +138,24 @@ func setParam(cmd *Command, argv interface{}, name string, tfield *reflect.Struc
 
 	if val != nil {
 		// we have a value...
+		complete, err := completer(argv, val, tfield)
+		if err != nil {
+			return err
+		}
+
 		cmd.params.Var(val, name, tfield.Tag.Get("descr"))
+		cmd.params.Param(cmd.params.NParams() - 1).Complete = complete
+
 		return nil
 	}
 
 	// then try variadics...
 	if val := vals.AsVariadicValue(vfield.Addr()); val != nil {
-		return setVariadic(cmd, name, val, tfield)
+		return setVariadic(cmd, argv, name, val, tfield)
 	}
 
 	if val := vals.AsVariadicCallback(vfield, argv); val != nil {
-		return setVariadic(cmd, name, val, tfield)
+		return setVariadic(cmd, argv, name, val, tfield)
 	}
 
 	// nothing worked, so we report an appropriate error
@@ -86,25 +166,153 @@ func setParam(cmd *Command, argv interface{}, name string, tfield *reflect.Struc
 	return interfaces.SpecErrorf("unsupported type for parameter %s: %q", name, tfield.Type.Kind())
 }
 
-func connectSpecsFlagsAndParams(cmd *Command, argv interface{}) error {
-	reflectVal := reflect.Indirect(reflect.ValueOf(argv))
+// argTags are the tags that make a struct field an argument or a flag group.
+var argTags = []string{"flag", "pos", "prefix"}
+
+// hasArgs reports whether the struct type t, or a pointer to it, has fields
+// with argument tags, directly or in embedded structs. Types in seen have
+// already been checked, so recursive types terminate.
+func hasArgs(t reflect.Type, seen map[reflect.Type]bool) bool {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	if t.Kind() != reflect.Struct || seen[t] {
+		return false
+	}
+
+	seen[t] = true
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		for _, tag := range argTags {
+			if _, ok := field.Tag.Lookup(tag); ok {
+				return true
+			}
+		}
+
+		if field.Anonymous && hasArgs(field.Type, seen) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// flagGroup returns the struct a field should be expanded into, if the field is
+// an embedded struct with arguments or a struct with a prefix tag, together with
+// the prefix that should be added to flag names inside the group. Structs in
+// expanding are already being expanded, and embedding them again is ignored.
+func flagGroup(tfield *reflect.StructField, vfield *reflect.Value, prefix string,
+	expanding map[reflect.Type]bool) (group reflect.Value, groupPrefix string, isGroup bool, err error) {
+	groupPrefix, hasPrefix := tfield.Tag.Lookup("prefix")
+	if !hasPrefix && !(tfield.Anonymous && hasArgs(tfield.Type, map[reflect.Type]bool{})) {
+		return reflect.Value{}, "", false, nil
+	}
+
+	typ := tfield.Type
+	if typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+
+	if typ.Kind() != reflect.Struct {
+		return reflect.Value{}, "", false, interfaces.SpecErrorf("prefix on %s, which is not a struct: %q", tfield.Name, tfield.Type.Kind())
+	}
+
+	if expanding[typ] {
+		if hasPrefix {
+			return reflect.Value{}, "", false, interfaces.SpecErrorf("flag group %s contains itself", tfield.Name)
+		}
+
+		return reflect.Value{}, "", false, nil // a recursive embedding adds no new flags
+	}
+
+	group = *vfield
+	if group.Kind() == reflect.Ptr {
+		if group.IsNil() {
+			if !group.CanSet() {
+				return reflect.Value{}, "", false, interfaces.SpecErrorf("cannot allocate flag group %s", tfield.Name)
+			}
+
+			group.Set(reflect.New(typ))
+		}
+
+		group = group.Elem()
+	}
+
+	return group, prefix + groupPrefix, true, nil
+}
+
+// connectStruct connects the fields in a struct, where flag names get prefix and
+// flags are shown under the heading group unless they have their own. The structs
+// in expanding are the ones we are already inside.
+func connectStruct(cmd *Command, argv interface{}, reflectVal reflect.Value, prefix, group string,
+	expanding map[reflect.Type]bool) error {
 	reflectTyp := reflectVal.Type()
 
+	expanding[reflectTyp] = true
+	defer delete(expanding, reflectTyp)
+
 	for i := 0; i < reflectTyp.NumField(); i++ {
 		tfield := reflectTyp.Field(i)
 		vfield := reflectVal.Field(i)
 
-		if name, isFlag := tfield.Tag.Lookup("flag"); isFlag {
-			if err := setFlag(cmd, argv, name, &tfield, &vfield); err != nil {
+		name, isFlag := tfield.Tag.Lookup("flag")
+		pname, isPos := tfield.Tag.Lookup("pos")
+
+		if (isFlag || isPos) && !vfield.CanInterface() {
+			return interfaces.SpecErrorf("field %s must be exported to be used as an argument", tfield.Name)
+		}
+
+		if isFlag {
+			if name != "" {
+				name = prefix + name
+			}
+
+			if err := setFlag(cmd, argv, name, group, &tfield, &vfield); err != nil {
 				return err
 			}
 		}
 
-		if name, isPos := tfield.Tag.Lookup("pos"); isPos {
-			if err := setParam(cmd, argv, name, &tfield, &vfield); err != nil {
+		if isPos {
+			if err := setParam(cmd, argv, pname, &tfield, &vfield); err != nil {
 				return err
 			}
 		}
+
+		if isFlag || isPos {
+			continue
+		}
+
+		fields, groupPrefix, isGroup, err := flagGroup(&tfield, &vfield, prefix, expanding)
+		if err != nil {
+			return err
+		}
+
+		if isGroup {
+			heading := group
+			if g, ok := tfield.Tag.Lookup("group"); ok {
+				heading = g
+			}
+
+			if err := connectStruct(cmd, argv, fields, groupPrefix, heading, expanding); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// connectSpecsFlagsAndParams connects the fields in argv to flags and positional
+// arguments. Embedded structs are flattened into the command's arguments, and
+// struct fields with a prefix tag are expanded with the prefix added to the
+// names of the flags they contain. A group tag on a flag, or on a struct field
+// that is expanded, puts the flags under that heading in usage. Callbacks in
+// nested structs still get the top-level argv as their argument.
+func connectSpecsFlagsAndParams(cmd *Command, argv interface{}) error {
+	if err := connectStruct(cmd, argv, reflect.Indirect(reflect.ValueOf(argv)), "", "", map[reflect.Type]bool{}); err != nil {
+		return err
 	}
 
 	return validateFlagsAndParams(cmd)
@@ -0,0 +1,236 @@
+package cli_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/mailund/cli"
+)
+
+type CommonArgs struct {
+	Verbose bool `flag:"verbose" short:"v" descr:"verbose output"`
+}
+
+type DBArgs struct {
+	Host string `flag:"host" descr:"database host"`
+	Port int    `flag:"port" descr:"database port"`
+}
+
+type AuthArgs struct {
+	User string `flag:"user" descr:"user name"`
+	DB   DBArgs `prefix:"db-"`
+}
+
+func TestEmbeddedFlagGroup(t *testing.T) {
+	type Args struct {
+		CommonArgs
+		X int `pos:"x"`
+	}
+
+	var argv *Args
+
+	cmd := cli.NewCommand(
+		cli.CommandSpec{
+			Init:   func() interface{} { return new(Args) },
+			Action: func(i interface{}) { argv, _ = i.(*Args) },
+		})
+
+	if err := cmd.RunError([]string{"-v", "42"}); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if !argv.Verbose || argv.X != 42 {
+		t.Errorf("arguments were not set correctly: %v", argv)
+	}
+}
+
+func TestPrefixFlagGroups(t *testing.T) {
+	type Args struct {
+		*CommonArgs
+		DB   DBArgs    `prefix:"db-"`
+		Auth *AuthArgs `prefix:"auth-"`
+	}
+
+	var argv *Args
+
+	cmd := cli.NewCommand(
+		cli.CommandSpec{
+			Name:   "groups",
+			Init:   func() interface{} { return &Args{DB: DBArgs{Port: 5432}} },
+			Action: func(i interface{}) { argv, _ = i.(*Args) },
+		})
+
+	args := []string{"--verbose", "--db-host=localhost", "--auth-user=me", "--auth-db-port", "42"}
+	if err := cmd.RunError(args); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if !argv.Verbose {
+		t.Error("verbose should be set")
+	}
+
+	if argv.DB.Host != "localhost" || argv.DB.Port != 5432 {
+		t.Errorf("db group was not set correctly: %v", argv.DB)
+	}
+
+	if argv.Auth.User != "me" || argv.Auth.DB.Port != 42 {
+		t.Errorf("auth group was not set correctly: %v", argv.Auth)
+	}
+
+	builder := new(strings.Builder)
+	cmd.SetOutput(builder)
+	cmd.Usage()
+
+	for _, flag := range []string{"--db-host", "--db-port", "--auth-user", "--auth-db-host", "--auth-db-port"} {
+		if !strings.Contains(builder.String(), flag) {
+			t.Errorf("expected %s in usage:\n%s", flag, builder.String())
+		}
+	}
+}
+
+func TestFlagGroupErrors(t *testing.T) {
+	type PrefixNonStruct struct {
+		X int `prefix:"x-"`
+	}
+
+	if _, err := cli.NewCommandError(cli.CommandSpec{
+		Init: func() interface{} { return new(PrefixNonStruct) },
+	}); err == nil || err.Error() != `prefix on X, which is not a struct: "int"` {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	type Duplicate struct {
+		CommonArgs
+		V bool `flag:"verbose"`
+	}
+
+	if _, err := cli.NewCommandError(cli.CommandSpec{
+		Init: func() interface{} { return new(Duplicate) },
+	}); err == nil || err.Error() != "flag verbose is defined more than once" {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	type Unexported struct {
+		x int `flag:"x"`
+	}
+
+	if _, err := cli.NewCommandError(cli.CommandSpec{
+		Init: func() interface{} { return new(Unexported) },
+	}); err == nil || err.Error() != "field x must be exported to be used as an argument" {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestUnexportedEmbeddedGroup(t *testing.T) {
+	type common struct {
+		X int `flag:"x"`
+	}
+
+	type Args struct {
+		common
+	}
+
+	argv := new(Args)
+	cmd := cli.NewCommand(
+		cli.CommandSpec{
+			Init: func() interface{} { return argv },
+		})
+
+	if err := cmd.RunError([]string{"-x", "42"}); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if argv.X != 42 {
+		t.Errorf("x was not set correctly: %d", argv.X)
+	}
+}
+
+type inner struct {
+	n int
+}
+
+type Node struct {
+	*Node
+	X int `flag:"x"`
+}
+
+type Tree struct {
+	X    int   `flag:"x"`
+	Left *Tree `prefix:"left-"`
+}
+
+func TestEmbeddedPointersWithoutArgs(t *testing.T) {
+	type Args struct {
+		*inner
+		*bytes.Buffer
+		X int `flag:"x"`
+	}
+
+	argv := new(Args)
+	if _, err := cli.NewCommandError(cli.CommandSpec{Init: func() interface{} { return argv }}); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if argv.inner != nil || argv.Buffer != nil {
+		t.Errorf("embedded structs without arguments should not be allocated: %v", argv)
+	}
+}
+
+func TestRecursiveFlagGroups(t *testing.T) {
+	argv := new(Node)
+	cmd := cli.NewCommand(cli.CommandSpec{Init: func() interface{} { return argv }})
+
+	if err := cmd.RunError([]string{"-x", "42"}); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if argv.X != 42 || argv.Node != nil {
+		t.Errorf("unexpected argv: %v", argv)
+	}
+
+	if _, err := cli.NewCommandError(cli.CommandSpec{
+		Init: func() interface{} { return new(Tree) },
+	}); err == nil || err.Error() != "flag group Left contains itself" {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestUsageTags(t *testing.T) {
+	type Args struct {
+		Port  int    `flag:"port" metavar:"PORT" descr:"port to listen on"`
+		Cache string `flag:"cache" defaultdescr:"$HOME/.cache" descr:"cache directory"`
+		CPUs  int    `flag:"cpus" showdefault:"false" descr:"number of workers"`
+	}
+
+	cmd := cli.NewCommand(
+		cli.CommandSpec{
+			Name: "server",
+			Init: func() interface{} { return &Args{Port: 8080, Cache: "/home/me/.cache", CPUs: 16} },
+		})
+
+	builder := new(strings.Builder)
+	cmd.SetOutput(builder)
+	cmd.Usage()
+
+	msg := builder.String()
+	for _, expected := range []string{
+		"--port PORT\n\tport to listen on (default 8080)",
+		"--cache string\n\tcache directory (default $HOME/.cache)",
+		"--cpus integer\n\tnumber of workers\n",
+	} {
+		if !strings.Contains(msg, expected) {
+			t.Errorf("expected %q in usage:\n%s", expected, msg)
+		}
+	}
+
+	type Invalid struct {
+		X int `flag:"x" showdefault:"maybe"`
+	}
+
+	if _, err := cli.NewCommandError(cli.CommandSpec{
+		Init: func() interface{} { return new(Invalid) },
+	}); err == nil || err.Error() != "unexpected showdefault value for flag x: maybe" {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
@@ -1,8 +1,8 @@
 package main
 
 import (
+	"fmt"
 	"io/ioutil"
-	"log"
 	"os"
 
 	"github.com/mailund/cli"
@@ -20,36 +20,28 @@ func initCat() interface{} {
 	}
 }
 
-func catAction(i interface{}) {
+func catAction(i interface{}) error {
 	a, _ := i.(*args)
 
 	buf, err := ioutil.ReadAll(a.In)
 	if err != nil {
-		log.Fatalf("error reading file: %s", err)
+		return fmt.Errorf("error reading file: %w", err)
 	}
 
-	err = a.In.Close()
-	if err != nil {
-		log.Fatalf("error closing file: %s", err)
-	}
-
-	_, err = a.Out.Write(buf)
-	if err != nil {
-		log.Fatalf("error writing file: %s", err)
+	if _, err = a.Out.Write(buf); err != nil {
+		return fmt.Errorf("error writing file: %w", err)
 	}
 
-	err = a.Out.Close()
-	if err != nil {
-		log.Fatalf("error closing file: %s", err)
-	}
+	// The command closes the files when the action is done
+	return nil
 }
 
 var catCmd = cli.NewCommand(
 	cli.CommandSpec{
-		Name:   "cat",
-		Long:   "Writes the content of one file to another.",
-		Init:   initCat,
-		Action: catAction,
+		Name:        "cat",
+		Long:        "Writes the content of one file to another.",
+		Init:        initCat,
+		ActionError: catAction,
 	})
 
 func main() {
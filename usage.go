@@ -0,0 +1,231 @@
+package cli
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/mailund/cli/interfaces"
+	"github.com/mailund/cli/internal/flags"
+)
+
+// UsageFlag describes a flag in UsageData.
+type UsageFlag struct {
+	Names       string // The flag's names, e.g. "-m,--mode"
+	Value       string // Description of the flag's value, or "" if it doesn't take one
+	Description string // The flag's description
+	Default     string // The default value as it should be shown, or "" if it shouldn't be
+	Group       string // The heading the flag is shown under, or "" for the default
+}
+
+// Help returns the flag's description followed by its default, if it should be shown.
+func (f UsageFlag) Help() string {
+	return f.Description + surround(" (default ", f.Default, ")")
+}
+
+// UsageFlagGroup is a list of flags shown under the same heading.
+type UsageFlagGroup struct {
+	Name  string      // The heading, or "" for flags without a group
+	Flags []UsageFlag // The flags in the group
+}
+
+// UsageParam describes a positional argument in UsageData.
+type UsageParam struct {
+	Name        string // The argument's name
+	Description string // The argument's description
+}
+
+// UsageCommand describes a subcommand in UsageData.
+type UsageCommand struct {
+	Name     string // The subcommand's name
+	Short    string // The subcommand's short description
+	Category string // The subcommand's category, or "" if it doesn't have one
+}
+
+// UsageCommandGroup is a list of subcommands in the same category.
+type UsageCommandGroup struct {
+	Name     string         // The category, or "" for commands without one
+	Commands []UsageCommand // The commands in the category
+}
+
+// UsageData is the data usage templates are executed with.
+type UsageData struct {
+	Name        string         // The command's name
+	Path        []string       // Names of the commands leading to this one, including it
+	Short       string         // The short description
+	Long        string         // The long description
+	ShortUsage  string         // The positional arguments in short form, e.g. "x y ..."
+	Flags       []UsageFlag    // The (visible) flags
+	Params      []UsageParam   // The positional arguments
+	Subcommands []UsageCommand // The (visible) subcommands, sorted by name unless the command keeps declaration order
+	Width       int            // The width of the terminal, from $COLUMNS, or 70 if it isn't set
+
+	Examples      []Example           // Examples of how to use the command
+	FlagGroups    []UsageFlagGroup    // The flags divided into groups, with the flags without a group first
+	CommandGroups []UsageCommandGroup // The subcommands divided into categories, with the commands without one first
+}
+
+// DefaultUsageTemplate is the template commands use for usage information,
+// unless they get another with the spec's UsageTemplate or SetUsageTemplate.
+// The template is executed with a *UsageData, and can use these functions:
+//
+//   - join: strings.Join
+//   - wrap width text: wraps text to lines of at most width columns, keeping
+//     paragraphs, lists, and indented blocks
+//   - indent width prefix text: wraps text so it fits in width columns when its
+//     lines start with prefix, and puts prefix in front of each line
+const DefaultUsageTemplate = `Usage: {{join .Path " "}} [flags] {{.ShortUsage}}
+
+{{if .Long}}{{wrap .Width .Long}}{{else}}{{wrap .Width .Short}}{{end}}
+
+
+{{if .Flags}}{{range $i, $g := .FlagGroups}}{{if $i}}
+{{end}}{{with .Name}}{{.}}{{else}}Flags{{end}}:
+{{range .Flags}}  {{.Names}}{{with .Value}} {{.}}{{end}}
+{{indent $.Width "\t" .Help}}
+{{end}}{{end}}{{end}}
+{{if .Params}}Arguments:
+{{range .Params}}  {{.Name}}
+{{indent $.Width "\t" .Description}}
+{{end}}{{end}}{{if .Examples}}
+Examples:
+{{range .Examples}}  {{.Cmdline}}
+{{with .Description}}{{indent $.Width "\t" .}}
+{{end}}{{end}}{{end}}{{if .Subcommands}}{{range $i, $g := .CommandGroups}}
+{{with .Name}}{{.}}{{else}}Commands{{end}}:
+{{range .Commands}}  {{.Name}}
+{{indent $.Width "\t" .Short}}
+{{end}}{{end}}
+{{end}}`
+
+// indent wraps text so it fits in width columns with prefix in front of each
+// line, and then puts prefix in front of each line.
+func indent(width int, prefix, text string) string {
+	lines := strings.Split(wordWrap(text, width-displayWidth(prefix)), "\n")
+	for i := range lines {
+		lines[i] = prefix + lines[i]
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// usageFuncs are the functions usage templates can use.
+var usageFuncs = template.FuncMap{
+	"join":   strings.Join,
+	"wrap":   func(width int, text string) string { return wordWrap(text, width) },
+	"indent": indent,
+}
+
+// surround returns x between pre and post, or the empty string if x is empty.
+func surround(pre, x, post string) string {
+	if x == "" {
+		return ""
+	}
+
+	return pre + x + post
+}
+
+// parseUsageTemplate parses the text of a usage template.
+func parseUsageTemplate(text string) (*template.Template, error) {
+	tmpl, err := template.New("usage").Funcs(usageFuncs).Parse(text)
+	if err != nil {
+		return nil, interfaces.SpecErrorf("error in usage template: %s", err)
+	}
+
+	return tmpl, nil
+}
+
+// SetUsageTemplate sets the template the command, and all its subcommands, use
+// for usage information. The template is executed with a *UsageData.
+func (cmd *Command) SetUsageTemplate(text string) error {
+	tmpl, err := parseUsageTemplate(text)
+	if err != nil {
+		return err
+	}
+
+	cmd.setUsageTemplate(tmpl)
+
+	return nil
+}
+
+func (cmd *Command) setUsageTemplate(tmpl *template.Template) {
+	for _, sub := range cmd.Subcommands {
+		sub.setUsageTemplate(tmpl)
+	}
+
+	cmd.usageTemplate = tmpl
+}
+
+func usageFlag(f *flags.Flag) UsageFlag {
+	return UsageFlag{
+		Names: f.Names(), Value: f.ValueUsage(), Description: f.Desc, Default: f.DefaultUsage(),
+		Group: f.Group,
+	}
+}
+
+// UsageData returns the information about the command that usage templates
+// are executed with.
+func (cmd *Command) UsageData() *UsageData {
+	data := &UsageData{
+		Name: cmd.Name, Path: cmd.Path(), Short: cmd.Short, Long: cmd.Long,
+		ShortUsage: cmd.params.ShortUsage(), Width: terminalWidth(), Examples: cmd.Examples,
+		Flags: []UsageFlag{}, Params: []UsageParam{}, Subcommands: []UsageCommand{},
+	}
+
+	for _, group := range cmd.flags.Groups() {
+		fg := UsageFlagGroup{Name: group.Name}
+
+		for _, f := range group.Flags {
+			fg.Flags = append(fg.Flags, usageFlag(f))
+		}
+
+		data.FlagGroups = append(data.FlagGroups, fg)
+	}
+
+	for _, f := range cmd.visibleFlags() {
+		data.Flags = append(data.Flags, usageFlag(f))
+	}
+
+	for i := 0; i < cmd.params.NParams(); i++ {
+		p := cmd.params.Param(i)
+		data.Params = append(data.Params, UsageParam{Name: p.Name, Description: p.Desc})
+	}
+
+	if vv := cmd.params.Variadic(); vv != nil {
+		data.Params = append(data.Params, UsageParam{Name: vv.Name, Description: vv.Desc})
+	}
+
+	categories := map[string]int{}
+
+	for _, sub := range cmd.visibleSubcommands() {
+		uc := UsageCommand{Name: sub.Name, Short: sub.Short, Category: sub.Category}
+		data.Subcommands = append(data.Subcommands, uc)
+
+		if _, ok := categories[sub.Category]; !ok {
+			categories[sub.Category] = len(data.CommandGroups)
+			data.CommandGroups = append(data.CommandGroups, UsageCommandGroup{Name: sub.Category})
+		}
+
+		i := categories[sub.Category]
+		data.CommandGroups[i].Commands = append(data.CommandGroups[i].Commands, uc)
+	}
+
+	// commands without a category go first
+	sort.SliceStable(data.CommandGroups, func(i, j int) bool {
+		return data.CommandGroups[i].Name == "" && data.CommandGroups[j].Name != ""
+	})
+
+	return data
+}
+
+// DefaultUsage creates a default function for printing usage information,
+// getting the information to print from the cmd object and formatting it
+// with the command's usage template.
+func DefaultUsage(cmd *Command) func() {
+	return func() {
+		if err := cmd.usageTemplate.Execute(cmd.Output(), cmd.UsageData()); err != nil {
+			fmt.Fprintf(cmd.Output(), "Error: %s\n", err)
+		}
+	}
+}
@@ -0,0 +1,206 @@
+package cli_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/mailund/cli"
+	"github.com/mailund/cli/interfaces"
+)
+
+func TestUsageTemplate(t *testing.T) {
+	type args struct {
+		N int    `flag:"n" descr:"a number"`
+		X string `pos:"x" descr:"a string"`
+	}
+
+	tmpl := `{{.Name}}: {{.Short}}
+{{range .Flags}}{{.Names}}={{.Value}} ({{.Default}})
+{{end}}{{range .Params}}{{.Name}}: {{.Description}}
+{{end}}`
+
+	cmd := cli.NewCommand(cli.CommandSpec{
+		Name:          "cmd",
+		Short:         "a command",
+		UsageTemplate: tmpl,
+		Init:          func() interface{} { return new(args) },
+	})
+
+	out := new(strings.Builder)
+	cmd.SetOutput(out)
+	cmd.Usage()
+
+	expected := "cmd: a command\n-h,--help= ()\n-n,--n=integer (0)\nx: a string\n"
+	if out.String() != expected {
+		t.Errorf("unexpected usage:\n%s", out.String())
+	}
+}
+
+func TestSetUsageTemplate(t *testing.T) {
+	root, out := toolTree(t)
+
+	if err := root.SetUsageTemplate(`{{join .Path " "}}:{{range .Subcommands}} {{.Name}}{{end}}`); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	tests := []struct {
+		args     []string
+		expected string
+	}{
+		{[]string{"-h"}, "tool: calc"},
+		{[]string{"calc", "-h"}, "tool calc: add"},
+		{[]string{"calc", "add", "-h"}, "tool calc add:"},
+	}
+
+	for _, tt := range tests {
+		out.Reset()
+
+		if err := root.RunError(tt.args); !errors.Is(err, cli.ErrHelp) {
+			t.Fatalf("expected help, got %v", err)
+		}
+
+		if out.String() != tt.expected {
+			t.Errorf("unexpected usage for %v: %q", tt.args, out.String())
+		}
+	}
+}
+
+func TestUsageTemplateErrors(t *testing.T) {
+	_, err := cli.NewCommandError(cli.CommandSpec{Name: "cmd", UsageTemplate: "{{.Name"})
+
+	var specErr *interfaces.SpecError
+	if !errors.As(err, &specErr) {
+		t.Errorf("expected a spec error, got %v", err)
+	}
+
+	cmd := cli.NewCommand(cli.CommandSpec{Name: "cmd"})
+	if err := cmd.SetUsageTemplate("{{end}}"); !errors.As(err, &specErr) {
+		t.Errorf("expected a spec error, got %v", err)
+	}
+}
+
+func TestDefaultUsageData(t *testing.T) {
+	root, _ := toolTree(t)
+	data := root.UsageData()
+
+	if len(data.Flags) != 4 || data.Flags[1].Names != "-m,--mode" ||
+		data.Flags[1].Value != "{fast,slow}" || data.Flags[1].Default != "fast" {
+		t.Errorf("unexpected flags: %+v", data.Flags)
+	}
+
+	if len(data.Subcommands) != 1 || data.Subcommands[0].Name != "calc" || data.Subcommands[0].Short != "a calculator" {
+		t.Errorf("unexpected subcommands: %+v", data.Subcommands)
+	}
+
+	if data.ShortUsage != "cmd ..." {
+		t.Errorf("unexpected short usage: %s", data.ShortUsage)
+	}
+}
+
+func TestFlagGroups(t *testing.T) {
+	type (
+		DB struct {
+			Host string `flag:"host" descr:"database host"`
+		}
+		args struct {
+			Verbose bool   `flag:"verbose" descr:"chatty output"`
+			Port    int    `flag:"port" descr:"port to listen on" group:"Network"`
+			DB      DB     `prefix:"db-" group:"Database"`
+			Addr    string `flag:"addr" descr:"address to listen on" group:"Network"`
+		}
+	)
+
+	cmd := cli.NewCommand(cli.CommandSpec{
+		Name: "server",
+		Init: func() interface{} { return new(args) },
+	})
+
+	out := new(strings.Builder)
+	cmd.SetOutput(out)
+	cmd.Usage()
+
+	expected := `Flags:
+  -h,--help
+	show help for server
+  --verbose [boolean] (no value = true)
+	chatty output (default false)
+
+Network:
+  --port integer
+	port to listen on (default 0)
+  --addr string
+	address to listen on
+
+Database:
+  --db-host string
+	database host
+`
+	if !strings.Contains(out.String(), expected) {
+		t.Errorf("unexpected usage:\n%s", out.String())
+	}
+}
+
+func TestCommandCategories(t *testing.T) {
+	newCmd := func(name, category string) *cli.Command {
+		return cli.NewCommand(cli.CommandSpec{Name: name, Short: name + " things", Category: category})
+	}
+
+	tests := []struct {
+		name     string
+		ordered  bool
+		expected string
+	}{
+		{"sorted", false, `
+Commands:
+  help
+	help things
+
+Data:
+  a
+	a things
+
+Management:
+  b
+	b things
+  c
+	c things
+`},
+		{"declaration order", true, `
+Commands:
+  help
+	help things
+
+Management:
+  c
+	c things
+  b
+	b things
+
+Data:
+  a
+	a things
+`},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			menu := cli.NewCommand(cli.CommandSpec{
+				Name: "tool",
+				Subcommands: []*cli.Command{
+					newCmd("c", "Management"), newCmd("a", "Data"), newCmd("b", "Management"), newCmd("help", ""),
+				},
+				DeclarationOrder: tt.ordered,
+			})
+
+			out := new(strings.Builder)
+			menu.SetOutput(out)
+			menu.Usage()
+
+			if !strings.HasSuffix(out.String(), tt.expected+"\n") {
+				t.Errorf("unexpected usage:\n%s", out.String())
+			}
+		})
+	}
+}
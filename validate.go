@@ -31,3 +31,23 @@ func validateFlagsAndParams(cmd *Command) error {
 
 	return nil
 }
+
+func validateArgs(argv interface{}) error {
+	v, ok := argv.(interfaces.ArgsValidator)
+	if !ok {
+		return nil
+	}
+
+	err := v.ValidateArgs()
+	if err == nil {
+		return nil
+	}
+
+	// Report errors as parse errors, so they are handled like other
+	// problems with the command line.
+	if perr, ok := err.(*interfaces.ParseError); ok {
+		return perr
+	}
+
+	return interfaces.NewParseError(interfaces.KindValidation, err, "%s", err)
+}
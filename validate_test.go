@@ -0,0 +1,83 @@
+package cli_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/mailund/cli"
+	"github.com/mailund/cli/interfaces"
+)
+
+type rangeArgs struct {
+	Start int `flag:"start"`
+	End   int `flag:"end"`
+}
+
+func (a *rangeArgs) ValidateArgs() error {
+	if a.End < a.Start {
+		return errors.New("--end must be after --start") //nolint:goerr113 // testing dynamic errors
+	}
+
+	return nil
+}
+
+func TestValidateArgs(t *testing.T) {
+	called := false
+	cmd := cli.NewCommand(
+		cli.CommandSpec{
+			Name:   "range",
+			Init:   func() interface{} { return new(rangeArgs) },
+			Action: func(interface{}) { called = true },
+		})
+
+	if err := cmd.RunError([]string{"--start=1", "--end=2"}); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if !called {
+		t.Error("the action should have been called")
+	}
+
+	called = false
+	err := cmd.RunError([]string{"--start=2", "--end=1"})
+
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+
+	if _, ok := err.(*interfaces.ParseError); !ok {
+		t.Errorf("expected a parse error, got %T", err)
+	}
+
+	if err.Error() != "--end must be after --start" {
+		t.Errorf("unexpected error: %s", err)
+	}
+
+	if called {
+		t.Error("the action should not be called when validation fails")
+	}
+}
+
+func TestValidateArgsRun(t *testing.T) {
+	failed := false
+	cmd := cli.NewCommand(
+		cli.CommandSpec{
+			Name: "range",
+			Init: func() interface{} { return new(rangeArgs) },
+		})
+
+	builder := new(strings.Builder)
+	cmd.SetOutput(builder)
+
+	cmd.SetExitFunc(func(int) { failed = true })
+	cmd.Run([]string{"--start=2", "--end=1"})
+
+	if !failed {
+		t.Error("the command should have failed")
+	}
+
+	if msg := builder.String(); !strings.HasPrefix(msg, "Error: --end must be after --start.\n\nUsage: range [flags]") {
+		t.Errorf("unexpected error message: %s", msg)
+	}
+}
@@ -0,0 +1,120 @@
+package cli
+
+import (
+	"fmt"
+	"runtime"
+	"runtime/debug"
+)
+
+// versionCommand is the name of the command from VersionCommand.
+const versionCommand = "version"
+
+// readBuildInfo reads the build information of the program; tests can replace it.
+var readBuildInfo = debug.ReadBuildInfo
+
+// root returns the top-level command of the tree cmd is part of.
+func (cmd *Command) root() *Command {
+	for cmd.parent != nil {
+		cmd = cmd.parent
+	}
+
+	return cmd
+}
+
+// programVersion returns the program's version: the Version from the nearest command,
+// on the path from cmd to the top-level command, that has one, or the module
+// version and VCS revision from the build information.
+func (cmd *Command) programVersion() string {
+	for c := cmd; c != nil; c = c.parent {
+		if c.Version != "" {
+			return c.Version
+		}
+	}
+
+	info, ok := readBuildInfo()
+	if !ok {
+		return "unknown"
+	}
+
+	version := info.Main.Version
+	if version == "" {
+		version = "(devel)"
+	}
+
+	if revision, modified := vcsRevision(info); revision != "" {
+		const shortRevision = 12
+		if len(revision) > shortRevision {
+			revision = revision[:shortRevision]
+		}
+
+		if modified {
+			revision += ", modified"
+		}
+
+		version += " (" + revision + ")"
+	}
+
+	return version
+}
+
+// writeVersion prints the program's name and version to the command's output.
+// If verbose is set, it also lists the Go version and the module versions the
+// program was built with.
+func (cmd *Command) writeVersion(verbose bool) error {
+	out := cmd.Output()
+
+	if _, err := fmt.Fprintf(out, "%s %s\n", cmd.root().Name, cmd.programVersion()); err != nil {
+		return err
+	}
+
+	if !verbose {
+		return nil
+	}
+
+	fmt.Fprintf(out, "\tgo\t%s\n", runtime.Version())
+
+	info, ok := readBuildInfo()
+	if !ok {
+		return nil
+	}
+
+	if info.Main.Path != "" {
+		fmt.Fprintf(out, "\tmod\t%s\t%s\n", info.Main.Path, info.Main.Version)
+	}
+
+	for _, dep := range info.Deps {
+		fmt.Fprintf(out, "\tdep\t%s\t%s\n", dep.Path, dep.Version)
+
+		if dep.Replace != nil {
+			fmt.Fprintf(out, "\t=>\t%s\t%s\n", dep.Replace.Path, dep.Replace.Version)
+		}
+	}
+
+	return nil
+}
+
+// VersionCommand returns a command, named version, that prints the program's
+// version, from the Version field in root's spec or, if that is empty, from the
+// module's build information. With the flag --verbose, it also lists the Go
+// version and the versions of the modules the program depends on. Add it to
+// root with AddSubcommands.
+func VersionCommand(root *Command) *Command {
+	type versionArgs struct {
+		Verbose bool `flag:"verbose" short:"v" descr:"list the versions of dependencies as well"`
+	}
+
+	var cmd *Command
+
+	cmd = NewCommand(CommandSpec{
+		Name:  versionCommand,
+		Short: "show the version",
+		Long:  "Shows the version of " + root.Name + ".",
+		Init:  func() interface{} { return new(versionArgs) },
+		ActionError: func(i interface{}) error {
+			args, _ := i.(*versionArgs)
+			return cmd.writeVersion(args.Verbose)
+		},
+	})
+
+	return cmd
+}
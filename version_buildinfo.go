@@ -0,0 +1,21 @@
+//go:build go1.18
+// +build go1.18
+
+package cli
+
+import "runtime/debug"
+
+// vcsRevision returns the VCS revision the program was built from, and whether
+// the working tree had local modifications, if the build information has them.
+func vcsRevision(info *debug.BuildInfo) (revision string, modified bool) {
+	for _, setting := range info.Settings {
+		switch setting.Key {
+		case "vcs.revision":
+			revision = setting.Value
+		case "vcs.modified":
+			modified = setting.Value == "true"
+		}
+	}
+
+	return revision, modified
+}
@@ -0,0 +1,24 @@
+//go:build go1.18
+// +build go1.18
+
+package cli // white box test, so we can fake the build information
+
+import (
+	"runtime/debug"
+	"testing"
+)
+
+func TestVersionRevision(t *testing.T) {
+	fakeBuildInfo(t, &debug.BuildInfo{
+		Main: debug.Module{Path: "example.com/tool", Version: "(devel)"},
+		Settings: []debug.BuildSetting{
+			{Key: "vcs.revision", Value: "0123456789abcdef0123"},
+			{Key: "vcs.modified", Value: "true"},
+		},
+	})
+
+	cmd := NewCommand(CommandSpec{Name: "tool"})
+	if version, expected := cmd.programVersion(), "(devel) (0123456789ab, modified)"; version != expected {
+		t.Errorf("expected %q, got %q", expected, version)
+	}
+}
@@ -0,0 +1,12 @@
+//go:build !go1.18
+// +build !go1.18
+
+package cli
+
+import "runtime/debug"
+
+// vcsRevision returns the VCS revision the program was built from, but before
+// Go 1.18, the build information doesn't have it.
+func vcsRevision(info *debug.BuildInfo) (revision string, modified bool) {
+	return "", false
+}
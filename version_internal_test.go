@@ -0,0 +1,96 @@
+package cli // white box test, so we can fake the build information
+
+import (
+	"errors"
+	"runtime"
+	"runtime/debug"
+	"strings"
+	"testing"
+)
+
+func fakeBuildInfo(t *testing.T, info *debug.BuildInfo) {
+	t.Helper()
+
+	old := readBuildInfo
+	readBuildInfo = func() (*debug.BuildInfo, bool) { return info, info != nil }
+
+	t.Cleanup(func() { readBuildInfo = old })
+}
+
+func TestVersion(t *testing.T) {
+	fakeBuildInfo(t, &debug.BuildInfo{
+		Main: debug.Module{Path: "example.com/tool", Version: "v1.2.3"},
+		Deps: []*debug.Module{
+			{Path: "github.com/mailund/cli", Version: "v0.1.0"},
+			{Path: "example.com/dep", Version: "v0.0.1", Replace: &debug.Module{Path: "../dep"}},
+		},
+	})
+
+	tests := []struct {
+		name     string
+		version  string
+		args     []string
+		expected string
+	}{
+		{"spec", "v2.0.0", []string{"--version"}, "tool v2.0.0\n"},
+		{"build info", "", []string{"--version"}, "tool v1.2.3\n"},
+		{"subcommand flag", "v2.0.0", []string{"sub", "--version"}, "tool v2.0.0\n"},
+		{"command", "", []string{"version"}, "tool v1.2.3\n"},
+		{"verbose", "v2.0.0", []string{"version", "-v"}, "tool v2.0.0\n" +
+			"\tgo\t" + runtime.Version() + "\n" +
+			"\tmod\texample.com/tool\tv1.2.3\n" +
+			"\tdep\tgithub.com/mailund/cli\tv0.1.0\n" +
+			"\tdep\texample.com/dep\tv0.0.1\n" +
+			"\t=>\t../dep\t\n"},
+	}
+
+	for _, tt := range tests {
+		sub := NewCommand(CommandSpec{Name: "sub", VersionFlag: true})
+		root := NewCommand(CommandSpec{Name: "tool", Version: tt.version, VersionFlag: true, Subcommands: []*Command{sub}})
+
+		if err := root.AddSubcommands(VersionCommand(root)); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+
+		out := new(strings.Builder)
+		root.SetOutput(out)
+
+		err := root.RunError(tt.args)
+		if err != nil && !errors.Is(err, ErrHelp) {
+			t.Fatalf("%s: unexpected error: %s", tt.name, err)
+		}
+
+		if out.String() != tt.expected {
+			t.Errorf("%s: expected %q, got %q", tt.name, tt.expected, out.String())
+		}
+	}
+}
+
+func TestVersionWithoutBuildInfo(t *testing.T) {
+	fakeBuildInfo(t, nil)
+
+	root := NewCommand(CommandSpec{Name: "tool"})
+	if err := root.AddSubcommands(VersionCommand(root)); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	out := new(strings.Builder)
+	root.SetOutput(out)
+
+	if err := root.RunError([]string{"version", "--verbose"}); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if expected := "tool unknown\n\tgo\t" + runtime.Version() + "\n"; out.String() != expected {
+		t.Errorf("expected %q, got %q", expected, out.String())
+	}
+}
+
+func TestNoVersionFlag(t *testing.T) {
+	cmd := NewCommand(CommandSpec{Name: "tool", Version: "v1.0.0"})
+	cmd.SetOutput(new(strings.Builder))
+
+	if err := cmd.RunError([]string{"--version"}); err == nil {
+		t.Errorf("expected an error for --version without VersionFlag")
+	}
+}
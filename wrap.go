@@ -1,25 +1,170 @@
 package cli
 
-import "strings"
+import (
+	"os"
+	"strconv"
+	"strings"
+	"unicode"
+)
 
-func wordWrap(x string, linewidth int) string {
-	words := strings.Fields(strings.TrimSpace(x))
-	if len(words) == 0 {
-		return "" // apparently, there are no words
+// defaultWidth is the width we format usage for if we cannot find the terminal's.
+const defaultWidth = 70
+
+// noWrap is a line width for wordWrap that doesn't wrap lines, for output that
+// is formatted elsewhere, but where we still want paragraphs and indentation.
+const noWrap = 1 << 30
+
+// terminalWidth returns the width of the terminal, from the COLUMNS environment
+// variable, or defaultWidth if it isn't set.
+func terminalWidth() int {
+	if cols, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil && cols > 0 {
+		return cols
+	}
+
+	return defaultWidth
+}
+
+// wideRanges are the ranges of runes that East Asian scripts display in two columns.
+var wideRanges = [][2]rune{
+	{0x1100, 0x115F}, {0x2E80, 0x303E}, {0x3041, 0x33FF}, {0x3400, 0x4DBF},
+	{0x4E00, 0x9FFF}, {0xA000, 0xA4CF}, {0xAC00, 0xD7A3}, {0xF900, 0xFAFF},
+	{0xFE30, 0xFE4F}, {0xFF00, 0xFF60}, {0xFFE0, 0xFFE6}, {0x1F300, 0x1F64F},
+	{0x1F900, 0x1F9FF}, {0x20000, 0x2FFFD}, {0x30000, 0x3FFFD},
+}
+
+// runeWidth returns the number of columns r takes up on a terminal.
+func runeWidth(r rune) int {
+	if unicode.In(r, unicode.Mn, unicode.Me, unicode.Cf) {
+		return 0 // combining marks and zero-width characters
+	}
+
+	for _, rng := range wideRanges {
+		if rng[0] <= r && r <= rng[1] {
+			return 2
+		}
+	}
+
+	return 1
+}
+
+// displayWidth returns the number of columns x takes up on a terminal,
+// with tabs taking up eight.
+func displayWidth(x string) int {
+	const tabWidth = 8
+
+	width := 0
+
+	for _, r := range x {
+		if r == '\t' {
+			width += tabWidth
+		} else {
+			width += runeWidth(r)
+		}
 	}
 
-	wrapped := words[0]
-	used := len(wrapped)
+	return width
+}
+
+// fill puts words on lines of at most width columns, indenting all lines
+// but the first with indent. Words that are too long get their own line.
+func fill(words []string, width int, indent string) []string {
+	lines := []string{words[0]}
+	used := displayWidth(words[0])
 
 	for _, word := range words[1:] {
-		if used+1+len(word) > linewidth {
-			wrapped += "\n" + word
-			used = len(word)
+		if w := displayWidth(word); used+1+w > width {
+			lines = append(lines, indent+word)
+			used = displayWidth(indent) + w
 		} else {
-			wrapped += " " + word
-			used += 1 + len(word)
+			lines[len(lines)-1] += " " + word
+			used += 1 + w
+		}
+	}
+
+	return lines
+}
+
+func isIndented(line string) bool {
+	return line != "" && (line[0] == ' ' || line[0] == '\t')
+}
+
+func isListItem(line string) bool {
+	return strings.HasPrefix(line, "- ") || strings.HasPrefix(line, "* ")
+}
+
+// commonIndent returns the white space that all the non-empty lines start with.
+func commonIndent(lines []string) string {
+	indent, first := "", true
+
+	for _, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+
+		lead := line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+		if first {
+			indent, first = lead, false
+		}
+
+		for !strings.HasPrefix(lead, indent) {
+			indent = indent[:len(indent)-1]
+		}
+	}
+
+	return indent
+}
+
+// wordWrap wraps x to lines of at most linewidth columns. Paragraphs, separated
+// by empty lines, are wrapped separately, and list items, lines starting with
+// "- " or "* ", are wrapped with a hanging indent. Lines that are indented and
+// do not continue a paragraph, such as code examples, are left as they are.
+// Indentation that all lines after the first share is removed, so text can be
+// indented to match the code it is written in.
+func wordWrap(x string, linewidth int) string {
+	lines := strings.Split(strings.TrimSpace(x), "\n")
+	indent := commonIndent(lines[1:])
+
+	for i := 1; i < len(lines); i++ {
+		lines[i] = strings.TrimPrefix(lines[i], indent)
+	}
+
+	var (
+		out     []string
+		par     []string // words in the paragraph we are collecting
+		hanging string   // indentation for the paragraph's continuation lines
+	)
+
+	flush := func() {
+		if len(par) > 0 {
+			out = append(out, fill(par, linewidth, hanging)...)
+		}
+
+		par, hanging = nil, ""
+	}
+
+	for _, line := range lines {
+		switch {
+		case strings.TrimSpace(line) == "":
+			flush()
+
+			if len(out) > 0 && out[len(out)-1] != "" {
+				out = append(out, "")
+			}
+
+		case isIndented(line) && len(par) == 0:
+			out = append(out, strings.TrimRight(line, " \t"))
+
+		case isListItem(line):
+			flush()
+
+			par, hanging = strings.Fields(line), "  "
+
+		default:
+			par = append(par, strings.Fields(line)...)
 		}
 	}
 
-	return wrapped
+	flush()
+
+	return strings.Join(out, "\n")
 }
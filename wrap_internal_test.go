@@ -1,6 +1,9 @@
 package cli // white box test
 
-import "testing"
+import (
+	"os"
+	"testing"
+)
 
 func TestWrapSimplifySpace(t *testing.T) {
 	x := "  foo\nbar\t\n\t\tbaz\t"
@@ -19,3 +22,63 @@ func TestWrapNewlines(t *testing.T) {
 		t.Errorf(`Expected "%s" but got "%s"`, expected, wrapped)
 	}
 }
+
+func TestWrapParagraphs(t *testing.T) {
+	tests := []struct {
+		name     string
+		text     string
+		width    int
+		expected string
+	}{
+		{"paragraphs", "foo bar\nbaz\n\n\n\nqux quux", 8, "foo bar\nbaz\n\nqux quux"},
+		{"list", "items:\n- foo bar baz\n* qux", 9, "items:\n- foo bar\n  baz\n* qux"},
+		{"code", "example:\n\n    tool --flag x\n\tother\nafter", 8, "example:\n\n    tool --flag x\n\tother\nafter"},
+		{"dedent", "first\n\tsecond\n\n\tthird\n\n\t\tcode", 70, "first second\n\nthird\n\n\tcode"},
+		{"long words", "abcdefghij k", 4, "abcdefghij\nk"},
+		{"wide runes", "日本語 日本語", 12, "日本語\n日本語"},
+		{"narrow runes", "æøå æøå", 7, "æøå æøå"},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			if wrapped := wordWrap(tt.text, tt.width); wrapped != tt.expected {
+				t.Errorf("Expected %q but got %q", tt.expected, wrapped)
+			}
+		})
+	}
+}
+
+func TestIndent(t *testing.T) {
+	if indented, expected := indent(16, "\t", "foo bar baz"), "\tfoo bar\n\tbaz"; indented != expected {
+		t.Errorf("Expected %q but got %q", expected, indented)
+	}
+
+	if indented, expected := indent(16, "\t", ""), "\t"; indented != expected {
+		t.Errorf("Expected %q but got %q", expected, indented)
+	}
+}
+
+func TestTerminalWidth(t *testing.T) {
+	old, set := os.LookupEnv("COLUMNS")
+
+	defer func() {
+		if set {
+			os.Setenv("COLUMNS", old)
+		} else {
+			os.Unsetenv("COLUMNS")
+		}
+	}()
+
+	os.Setenv("COLUMNS", "120")
+
+	if width := terminalWidth(); width != 120 {
+		t.Errorf("Expected width 120, got %d", width)
+	}
+
+	os.Setenv("COLUMNS", "wide")
+
+	if width := terminalWidth(); width != defaultWidth {
+		t.Errorf("Expected the default width, got %d", width)
+	}
+}